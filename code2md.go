@@ -0,0 +1,262 @@
+// Package code2md は、ファイルやディレクトリの内容を集めて
+// Markdownのコードブロック形式にまとめるためのライブラリです
+//
+// コマンドラインツール (cmd は ./code2md) と同じ探索・変換の規則を使用します
+//
+//	res, err := code2md.Bundle(ctx, []string{"./src"}, code2md.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	return code2md.RenderMarkdown(w, res)
+package code2md
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+
+	"github.com/your-org/code2md/internal/lang"
+	"github.com/your-org/code2md/internal/logging"
+	"github.com/your-org/code2md/internal/markdown"
+	"github.com/your-org/code2md/internal/scan"
+)
+
+// LockfileMode は、ロックファイル (go.sum, package-lock.json など) の出力方法
+type LockfileMode string
+
+const (
+	LockfileFull    LockfileMode = "full"    // 内容をそのまま出力 (デフォルト)
+	LockfileSummary LockfileMode = "summary" // パッケージとバージョンの要約を出力
+	LockfileOmit    LockfileMode = "omit"    // 出力しない
+)
+
+// Truncation は、長いファイルの先頭 Head 行と末尾 Tail 行だけを残す設定
+// 両方が 0 の場合は切り詰めを行いません
+type Truncation struct {
+	Head int
+	Tail int
+}
+
+// TruncateRule は、globパターンに一致するファイルに適用する切り詰め設定
+// パターンに '/' を含まない場合はファイル名、含む場合は相対パスと照合します
+type TruncateRule struct {
+	Pattern string
+	Truncation
+}
+
+// Options は、Bundle の設定
+// ゼロ値はコマンドラインツールのデフォルトと同じ動作になります
+type Options struct {
+	// 探索
+	Ignore           []string // 無視するディレクトリ名やファイル名のパターン
+	IncludeDotfiles  bool     // '.' で始まるファイルやディレクトリも含める
+	NoDefaultIgnores bool     // デフォルトの無視ディレクトリパターンを適用しない
+	AllowSensitive   bool     // 秘密鍵や .env などの機密ファイルも含める
+	MaxFileSize      int64    // 1ファイルあたりの最大バイト数 (0は無制限)
+	MaxTotalSize     int64    // 対象ファイルの合計最大バイト数 (0は無制限)
+	IncludeGenerated bool     // 生成コード・圧縮済みファイル・vendored ディレクトリも含める
+
+	// 変換
+	Truncate            Truncation        // すべてのファイルに適用する切り詰め設定
+	TruncateRules       []TruncateRule    // パターンごとの切り詰め設定 (Truncate より優先)
+	Lockfiles           LockfileMode      // ロックファイルの出力方法 (空の場合は LockfileFull)
+	BinaryPlaceholders  bool              // バイナリファイルをスキップする代わりにメタデータを含める
+	SummarizeGenerated  bool              // 生成コード・圧縮済みファイルをスキップする代わりに要約を含める
+	NotebookOutputLines int               // ノートブックのセル出力を残す行数 (0は出力しない)
+	SampleRows          int               // データファイルを要約する件数 (0は要約しない)
+	KeepBOM             bool              // 先頭の BOM を取り除かない
+	KeepCRLF            bool              // 改行コード CRLF / CR を LF に変換しない
+	TrimTrailingSpace   bool              // 各行末の空白とタブを取り除く
+	Languages           map[string]string // 拡張子・ファイル名・パターンと言語タグの対応
+	Jobs                int               // 並列に読み込むファイル数 (0以下はCPU数)
+
+	// Log は、除外したファイルや警告などの診断メッセージの出力先
+	// すべてのレベルのメッセージをテキスト形式で出力します。nil の場合は出力しません
+	Log io.Writer
+
+	// Logger は、診断メッセージを出力するロガー (指定した場合は Log より優先)
+	// レベルや形式を選ぶ場合に使用します
+	Logger *slog.Logger
+}
+
+// Stats は、内容の行数・単語数・文字数
+type Stats struct {
+	Lines int
+	Words int
+	Chars int
+}
+
+// File は、変換した1ファイル分の結果
+type File struct {
+	Path     string // 実行ディレクトリからの相対パス (コードブロックの見出しに使用)
+	AbsPath  string // ファイルの絶対パス (アーカイブ内のファイルは "<アーカイブの絶対パス>/<アーカイブ内のパス>"、BundleFS では FS 内のパス)
+	Lang     string // 言語タグ (判定できない場合は空)
+	Encoding string // 元の文字コード ("UTF-8", "Shift_JIS" など)
+	Binary   bool   // バイナリファイルのメタデータかどうか (Options.BinaryPlaceholders)
+	// Generated は、生成コードの要約かどうか (Options.SummarizeGenerated)
+	Generated bool
+	Content   string // 出力する内容 (末尾の改行は1つ)
+	Omitted   int    // 切り詰めで省略した行数
+	Stats     Stats  // Content の統計情報
+}
+
+// Result は、Bundle の結果
+type Result struct {
+	Files []File
+	Total Stats // バイナリファイルを除く全ファイルの統計情報の合計
+}
+
+// Bundle は、paths で指定されたファイルやディレクトリから対象のファイルを集めて変換します
+// .zip, .tar, .tar.gz, .tgz のアーカイブはメモリ上に展開して、その内容を対象とします
+// ctx がキャンセルされた場合は処理を中断し、ctx.Err() を返します
+func Bundle(ctx context.Context, paths []string, opt Options) (*Result, error) {
+	mdOpts, err := opt.markdownOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := scan.GatherContext(ctx, paths, opt.scanOptions())
+	if err != nil {
+		return nil, err
+	}
+	return convert(ctx, files, mdOpts)
+}
+
+// BundleFS は、Bundle と同様に、ファイルシステム fsys 内の roots から対象のファイルを集めて変換します
+// roots は fsys 内のパス ("src" や "cmd/main.go" など) で、空の場合は fsys 全体を対象とします
+// 結果の File.Path と File.AbsPath には fsys 内のパスが設定されます
+func BundleFS(ctx context.Context, fsys fs.FS, roots []string, opt Options) (*Result, error) {
+	mdOpts, err := opt.markdownOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := scan.GatherFS(ctx, fsys, roots, opt.scanOptions())
+	if err != nil {
+		return nil, err
+	}
+	return convert(ctx, files, mdOpts)
+}
+
+// convert は、集めたファイルを変換して Result にまとめます
+func convert(ctx context.Context, files []scan.File, mdOpts markdown.Options) (*Result, error) {
+	res := &Result{}
+	err := markdown.Convert(ctx, markdownFiles(files), mdOpts, func(d markdown.Document) error {
+		f := File{
+			Path:      d.RelPath,
+			AbsPath:   d.Path,
+			Lang:      d.Lang,
+			Encoding:  d.Encoding,
+			Binary:    d.Binary,
+			Generated: d.Generated,
+			Content:   d.Content,
+			Omitted:   d.Omitted,
+			Stats:     Stats(d.Stats),
+		}
+		res.Files = append(res.Files, f)
+		if !f.Binary && !f.Generated {
+			res.Total.Lines += f.Stats.Lines
+			res.Total.Words += f.Stats.Words
+			res.Total.Chars += f.Stats.Chars
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// markdownFiles は、探索で集めたファイルを変換の入力に変換します
+func markdownFiles(files []scan.File) []markdown.File {
+	out := make([]markdown.File, len(files))
+	for i, f := range files {
+		out[i] = markdown.File{Path: f.Path, Lang: f.Lang, Generated: f.Generated, FS: f.FS, Name: f.Name}
+	}
+	return out
+}
+
+// scanOptions は、探索の設定を内部の形式に変換します
+func (opt Options) scanOptions() scan.Options {
+	return scan.Options{
+		UserIgnorePatterns:  opt.Ignore,
+		IncludeDotfiles:     opt.IncludeDotfiles,
+		ApplyDefaultIgnores: !opt.NoDefaultIgnores,
+		AllowSensitive:      opt.AllowSensitive,
+		MaxFileSize:         opt.MaxFileSize,
+		MaxTotalSize:        opt.MaxTotalSize,
+		IncludeGenerated:    opt.IncludeGenerated,
+		SummarizeGenerated:  opt.SummarizeGenerated,
+		Logger:              opt.logger(),
+	}
+}
+
+// logger は、診断メッセージを出力するロガーを返します
+func (opt Options) logger() *slog.Logger {
+	if opt.Logger != nil || opt.Log == nil {
+		return opt.Logger
+	}
+	l, _ := logging.New(opt.Log, logging.FormatText, logging.LevelTrace)
+	return l
+}
+
+// markdownOptions は、変換の設定を検証して内部の形式に変換します
+func (opt Options) markdownOptions() (markdown.Options, error) {
+	lockfiles, err := markdown.ParseLockfileMode(string(opt.Lockfiles))
+	if err != nil {
+		return markdown.Options{}, err
+	}
+	if opt.Truncate.Head < 0 || opt.Truncate.Tail < 0 {
+		return markdown.Options{}, fmt.Errorf("invalid truncation %d:%d", opt.Truncate.Head, opt.Truncate.Tail)
+	}
+
+	mdOpts := markdown.Options{
+		Truncate:            markdown.Truncation(opt.Truncate),
+		IncludeGenerated:    opt.IncludeGenerated,
+		Lockfiles:           lockfiles,
+		BinaryPlaceholders:  opt.BinaryPlaceholders,
+		SummarizeGenerated:  opt.SummarizeGenerated,
+		NotebookOutputLines: opt.NotebookOutputLines,
+		SampleRows:          opt.SampleRows,
+		Normalize: markdown.Normalize{
+			StripBOM:          !opt.KeepBOM,
+			CRLFToLF:          !opt.KeepCRLF,
+			TrimTrailingSpace: opt.TrimTrailingSpace,
+		},
+		Languages: lang.Mapping(opt.Languages),
+		Jobs:      opt.Jobs,
+		Logger:    opt.logger(),
+	}
+	for _, r := range opt.TruncateRules {
+		if r.Head < 0 || r.Tail < 0 {
+			return markdown.Options{}, fmt.Errorf("invalid truncation %d:%d for %q", r.Head, r.Tail, r.Pattern)
+		}
+		mdOpts.TruncateRules = append(mdOpts.TruncateRules, markdown.TruncateRule{
+			Pattern:    r.Pattern,
+			Truncation: markdown.Truncation(r.Truncation),
+		})
+	}
+	return mdOpts, nil
+}
+
+// RenderMarkdown は、Bundle の結果をコマンドラインツールと同じMarkdown形式で書き出します
+// コードブロックの区切りは、書き出す時点の Content に含まれるバッククォートの並びから決めます
+func RenderMarkdown(w io.Writer, r *Result) error {
+	for _, f := range r.Files {
+		err := markdown.WriteDocument(w, markdown.Document{
+			Path:      f.AbsPath,
+			RelPath:   f.Path,
+			Lang:      f.Lang,
+			Encoding:  f.Encoding,
+			Binary:    f.Binary,
+			Generated: f.Generated,
+			Content:   f.Content,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/your-org/code2md/internal/logging"
+	"github.com/your-org/code2md/internal/markdown"
+	"github.com/your-org/code2md/internal/scan"
+)
+
+var explainRoot string
+
+func explainCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <paths...>",
+		Short: "ファイルが出力に含まれるか、除外される場合はどの規則によるものかを表示します",
+		Long: `explain は、--root で指定したディレクトリ (デフォルトはカレントディレクトリ) を
+code2md に渡した場合に、指定したパスがどう扱われるかを表示します。
+除外される場合は、判定した規則 (dotfile, default ignore, user pattern, sensitive,
+generated, size limit など) と一致したパターン、含めるためのオプションを表示します。
+code2md は .gitignore を参照しないため、.gitignore による除外は行われません。`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runExplain,
+	}
+	cmd.Flags().StringVar(&explainRoot, "root", ".",
+		"探索の起点とするディレクトリ")
+	return cmd
+}
+
+// runExplain は、各パスの扱いと判定した規則を表示します
+func runExplain(cmd *cobra.Command, args []string) error {
+	_, logger, _, err := newLogger()
+	if err != nil {
+		return err
+	}
+	opts, mdOpts, err := buildOptions(logger)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	for _, p := range args {
+		d, err := scan.Explain(explainRoot, p, opts)
+		if err != nil {
+			return err
+		}
+		if !d.Included {
+			fmt.Fprintf(w, "%s: excluded by %s\n", p, describeDecision(d, p))
+			continue
+		}
+
+		// 内容による判定 (バイナリ、生成コードのヘッダー、ロックファイルなど) は変換して確認する
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			reason, ok := convertedReason(p, d.Reason, mdOpts)
+			if !ok {
+				fmt.Fprintf(w, "%s: excluded when converting: %s\n", p, reason)
+				continue
+			}
+			if reason != "" {
+				fmt.Fprintf(w, "%s: included as summary (generated: %s)\n", p, reason)
+				continue
+			}
+		}
+		fmt.Fprintf(w, "%s: included\n", p)
+	}
+
+	if opts.MaxTotalSize > 0 {
+		fmt.Fprintln(w, "note: --max-total-size depends on the order files are gathered and is not evaluated here")
+	}
+	if hasGitignore(explainRoot) {
+		fmt.Fprintln(w, "note: code2md does not read .gitignore; files listed there are included unless a rule above excludes them")
+	}
+	return nil
+}
+
+// describeDecision は、除外した規則を1行で説明します
+func describeDecision(d scan.Decision, target string) string {
+	s := d.Rule
+	if d.Pattern != "" {
+		s += fmt.Sprintf(" %q", d.Pattern)
+	}
+
+	var details []string
+	if abs, err := filepath.Abs(target); err == nil && abs != d.Path {
+		details = append(details, "at "+relPath(d.Path))
+	}
+	if d.Reason != "" {
+		details = append(details, d.Reason)
+	}
+	if d.Hint != "" {
+		details = append(details, "use "+d.Hint+" to include it")
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, "; ") + ")"
+	}
+	return s
+}
+
+// convertedReason は、ファイルを変換して出力されるかを確認し、出力されない場合はその理由を返します
+// 生成コードとして要約のみを出力する場合は、生成コードと判定した理由を返します
+// generated は、収集時に生成コードと判定した理由 (scan.Decision.Reason) です
+func convertedReason(path, generated string, opt markdown.Options) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err.Error(), false
+	}
+	target, _ := logging.New(io.Discard, logging.FormatText, slog.LevelDebug)
+	rec := logging.NewRecorder(target.Handler())
+	opt.Logger = slog.New(rec)
+
+	converted, summarized := false, false
+	markdown.Measure(context.Background(), []markdown.File{{Path: abs, Generated: generated}}, opt, func(d markdown.Document) error {
+		converted, summarized = true, d.Generated
+		return nil
+	})
+	if converted {
+		if !summarized {
+			return "", true
+		}
+		if generated != "" {
+			return generated, true
+		}
+		// 内容による判定の理由はログに記録される
+		return strings.TrimPrefix(recordedAttr(rec, "reason"), "generated: "), true
+	}
+
+	// 除外した理由は最後のメッセージに記録される
+	records := rec.Records()
+	if len(records) == 0 {
+		return "unknown", false
+	}
+	return describeRecord(records[len(records)-1]), false
+}
+
+// describeRecord は、ファイルを出力しなかった理由のメッセージを1行にまとめます
+func describeRecord(r slog.Record) string {
+	reason := r.Message
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "reason", "type", "err", "hint":
+			reason += ", " + a.Key + "=" + a.Value.String()
+		}
+		return true
+	})
+	return reason
+}
+
+// recordedAttr は、記録したメッセージのうち key を持つ最後の属性の値を返します
+func recordedAttr(rec *logging.Recorder, key string) string {
+	value := ""
+	for _, r := range rec.Records() {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == key {
+				value = a.Value.String()
+			}
+			return true
+		})
+	}
+	return value
+}
+
+// relPath は、カレントディレクトリからの相対パスを返します (取得できない場合はそのまま返します)
+func relPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// hasGitignore は、dir からリポジトリのルートまでに .gitignore があるかを返します
+func hasGitignore(dir string) bool {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".gitignore")); err == nil {
+			return true
+		}
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil || filepath.Dir(d) == d {
+			return false
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/your-org/code2md/internal/markdown"
+	"github.com/your-org/code2md/internal/scan"
+)
+
+// bytesPerToken は、トークン数の目安を計算する際の1トークンあたりのバイト数
+// (英語やソースコードでは、一般的なトークナイザーで約4バイトが1トークンになる)
+const bytesPerToken = 4
+
+func listCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls [paths...]",
+		Short: "出力せずに、対象のファイルとサイズ・トークン数の目安を一覧表示します",
+		Long: `ls は、code2md が出力するファイルを、内容を出力せずに一覧表示します。
+探索の規則は通常の実行と同じで、除外したファイルは -v で確認できます。
+出力と同じ変換を行い、バイナリファイルや生成コードのヘッダーを持つファイル、
+--lockfiles omit のロックファイルなど、変換時に除外されるファイルは理由とともに
+スキップする (skip) と表示します。トークン数は、切り詰めや要約の後の内容を
+4 バイトを 1 トークンとして数えた目安です。`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runList,
+	}
+}
+
+// runList は、対象のファイルを一覧表示します (code2md ls, --dry-run)
+// 出力と同じ変換を行い、変換時に除外されるファイル (バイナリ、生成コードのヘッダー、
+// --lockfiles omit など) は理由とともに skip と表示します
+func runList(cmd *cobra.Command, args []string) error {
+	base, logger, counter, err := newLogger()
+	if err != nil {
+		return err
+	}
+	opts, mdOpts, err := buildOptions(logger)
+	if err != nil {
+		return err
+	}
+
+	files, err := scan.Gather(args, opts)
+	if err != nil {
+		return err
+	}
+
+	drops := &dropLog{target: logger.Handler(), reasons: map[string]string{}}
+	mdOpts.Logger = slog.New(drops)
+	converted := map[string]listed{}
+	// 大きなファイルは内容を保持せずに読み通し、出力する内容のバイト数だけを数える
+	err = markdown.Measure(context.Background(), markdownFiles(files), mdOpts, func(d markdown.Document) error {
+		converted[d.Path] = listed{size: d.Size, meta: d.Binary || d.Generated}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	fmt.Fprintf(w, "%10s  %8s  %s\n", "SIZE", "TOKENS", "PATH")
+
+	var count int
+	var totalSize, totalTokens int64
+	for _, f := range files {
+		info, err := f.Stat()
+		if err != nil {
+			logger.Error("could not read file", "path", f.Path, "err", err)
+			continue
+		}
+
+		path := f.Path
+		if filepath.IsAbs(path) {
+			path = relPath(path)
+		}
+
+		l, ok := converted[f.Path]
+		if !ok {
+			reason := drops.reasons[path]
+			if reason == "" {
+				reason = "excluded when converting"
+			}
+			fmt.Fprintf(w, "%10s  %8s  %s (%s)\n", scan.FormatSize(info.Size()), "skip", path, reason)
+			continue
+		}
+
+		tokens := "-"
+		if !l.meta {
+			// 切り詰めや要約の後に出力される内容から数える
+			n := estimateTokens(l.size)
+			tokens = "~" + fmt.Sprint(n)
+			totalTokens += n
+		}
+		fmt.Fprintf(w, "%10s  %8s  %s\n", scan.FormatSize(info.Size()), tokens, path)
+		count++
+		totalSize += info.Size()
+	}
+	fmt.Fprintf(w, "%d files, %s, ~%d tokens\n", count, scan.FormatSize(totalSize), totalTokens)
+
+	return exitWith(cmd, finish(base, counter, count))
+}
+
+// listed は、一覧に表示する1ファイル分の変換結果
+type listed struct {
+	size int64 // 出力する内容のバイト数
+	meta bool  // バイナリファイルのメタデータや生成コードの要約のみを出力するかどうか
+}
+
+// dropLog は、変換時のメッセージを target に出力しながら、ファイルごとに最後のメッセージを記録します
+// 除外の理由を表示するため、target の出力レベルにかかわらず Debug レベルのメッセージも記録します
+// (markdown.Measure はメッセージを1つのゴルーチンから順に出力するため、排他制御は行いません)
+type dropLog struct {
+	target  slog.Handler
+	reasons map[string]string // path 属性の値から最後のメッセージへの対応
+}
+
+func (h *dropLog) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *dropLog) Handle(ctx context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "path" {
+			h.reasons[a.Value.String()] = describeRecord(r)
+			return false
+		}
+		return true
+	})
+	if !h.target.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.target.Handle(ctx, r)
+}
+
+func (h *dropLog) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &dropLog{target: h.target.WithAttrs(attrs), reasons: h.reasons}
+}
+
+func (h *dropLog) WithGroup(name string) slog.Handler {
+	return &dropLog{target: h.target.WithGroup(name), reasons: h.reasons}
+}
+
+// estimateTokens は、ファイルサイズからトークン数の目安を返します
+func estimateTokens(size int64) int64 {
+	return (size + bytesPerToken - 1) / bytesPerToken
+}
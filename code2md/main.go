@@ -1,9 +1,18 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/your-org/code2md/internal/config"
+	"github.com/your-org/code2md/internal/lang"
+	"github.com/your-org/code2md/internal/logging"
 	"github.com/your-org/code2md/internal/markdown"
 	"github.com/your-org/code2md/internal/scan"
 )
@@ -12,9 +21,60 @@ var (
 	ignorePatterns   []string
 	includeDotfiles  bool
 	noDefaultIgnores bool
+	allowSensitive   bool
+	maxFileSize      string
+	maxTotalSize     string
+	headLines        int
+	tailLines        int
+	truncateRules    []string
+	includeGenerated bool
+	summarizeGen     bool
+	lockfileMode     string
+	binaryMeta       bool
+	notebookOutputs  int
+	sampleRows       int
+	stripBOM         bool
+	crlfToLF         bool
+	trimTrailing     bool
+	langMaps         []string
+	langMapFile      string
+	jobs             int
+	quiet            bool
+	verbose          int
+	logFormat        string
+	strict           bool
+	dryRun           bool
+	output           string
+	configFile       string
+	profile          string
 )
 
+// loadedConfigs は、読み込んだ設定ファイル (-v で表示します)
+var loadedConfigs []string
+
+// 終了コード
+const (
+	exitUsage           = 1 // 引数の誤りや出力の失敗
+	exitNothingGathered = 2 // 出力するファイルが1つもない
+	exitPartialFailure  = 3 // 一部のパスやファイルを読み込めなかった (--strict では警告も含む)
+)
+
+// maxFailedPaths は、終了時の概要に表示するエラーのパスの最大数
+const maxFailedPaths = 10
+
 func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		var exit exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		os.Exit(exitUsage)
+	}
+}
+
+// newRootCommand は、サブコマンドとフラグを登録したコマンドを作成します
+// フラグの値を保持する変数は、作成時にデフォルト値に戻ります
+func newRootCommand() *cobra.Command {
 	root := &cobra.Command{
 		Use:   "code2md [paths...]",
 		Short: "指定されたファイルやディレクトリの内容をMarkdownコードブロック形式で出力します",
@@ -22,29 +82,369 @@ func main() {
 Markdownのコードブロック形式で標準出力するコマンドラインツールです。
 特定のディレクトリパターンや、ドットから始まるファイル/ディレクトリを
 デフォルトで除外する機能を備えています。`,
-		Args: cobra.MinimumNArgs(1),
+		Args:              cobra.MinimumNArgs(1),
+		PersistentPreRunE: applyConfig,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts := scan.Options{
-				UserIgnorePatterns:  ignorePatterns,
-				IncludeDotfiles:     includeDotfiles,
-				ApplyDefaultIgnores: !noDefaultIgnores,
-			}
-			files, err := scan.Gather(args, opts)
-			if err != nil {
-				return err
+			if dryRun {
+				return runList(cmd, args)
 			}
-			return markdown.Print(os.Stdout, files)
+			return runBundle(cmd, args)
 		},
 	}
+	root.AddCommand(listCommand(), explainCommand())
 
-	root.Flags().StringSliceVarP(&ignorePatterns, "ignore", "i", nil,
+	root.PersistentFlags().StringSliceVarP(&ignorePatterns, "ignore", "i", nil,
 		"無視するディレクトリ名やファイル名のパターン (カンマ区切りで複数指定可: --ignore \"*.md,*.py,*.json\")")
-	root.Flags().BoolVar(&includeDotfiles, "include-dotfiles", false,
+	root.PersistentFlags().BoolVar(&includeDotfiles, "include-dotfiles", false,
 		"'.'で始まるファイルやディレクトリを処理対象に含める")
-	root.Flags().BoolVar(&noDefaultIgnores, "no-default-ignores", false,
+	root.PersistentFlags().BoolVar(&noDefaultIgnores, "no-default-ignores", false,
 		"デフォルトの無視ディレクトリパターンを適用しない")
+	root.PersistentFlags().BoolVar(&allowSensitive, "allow-sensitive", false,
+		"秘密鍵や .env などの機密ファイルも処理対象に含める")
+	root.PersistentFlags().StringVar(&maxFileSize, "max-file-size", "",
+		"これより大きいファイルを除外する (例: 500KB, 2MB)")
+	root.PersistentFlags().StringVar(&maxTotalSize, "max-total-size", "",
+		"出力対象ファイルの合計サイズの上限 (例: 10MB)")
+	root.PersistentFlags().BoolVar(&includeGenerated, "include-generated", false,
+		"生成コード・圧縮済みファイル・vendor ディレクトリも処理対象に含める")
+	root.PersistentFlags().BoolVar(&summarizeGen, "summarize-generated", false,
+		"生成コード・圧縮済みファイルをスキップする代わりに、理由・サイズ・行数・ヘッダーを出力する")
+	root.PersistentFlags().StringVar(&lockfileMode, "lockfiles", "full",
+		"ロックファイル (go.sum, package-lock.json など) の出力方法: full (そのまま), summary (依存パッケージの要約), omit (出力しない)")
+	root.PersistentFlags().BoolVar(&binaryMeta, "binary-placeholders", false,
+		"バイナリファイルをスキップする代わりに、パス・サイズ・MIMEタイプ・画像サイズ・SHA-256を出力する")
+	root.PersistentFlags().IntVar(&notebookOutputs, "notebook-output-lines", 0,
+		"Jupyter ノートブックのセル出力を残す行数 (0で出力しない)")
+	root.PersistentFlags().IntVar(&sampleRows, "sample-rows", 0,
+		"CSV/TSVは先頭N行、JSONの配列は先頭N件、NDJSON/ログは先頭と末尾N行に要約する (0で要約しない)")
+	root.PersistentFlags().BoolVar(&stripBOM, "strip-bom", true,
+		"先頭の BOM を取り除く (--strip-bom=false で無効化)")
+	root.PersistentFlags().BoolVar(&crlfToLF, "crlf-to-lf", true,
+		"改行コード CRLF を LF に変換する (--crlf-to-lf=false で無効化)")
+	root.PersistentFlags().BoolVar(&trimTrailing, "trim-trailing-space", false,
+		"各行末の空白を取り除く")
+	root.PersistentFlags().StringArrayVar(&langMaps, "lang-map", nil,
+		"拡張子またはファイル名と言語タグの対応を追加する (例: --lang-map .tmpl=gotemplate --lang-map Jenkinsfile.ci=groovy)")
+	root.PersistentFlags().StringVar(&langMapFile, "lang-map-file", "",
+		"拡張子またはファイル名と言語タグの対応を記述したYAMLファイル")
+	root.PersistentFlags().IntVarP(&jobs, "jobs", "j", 0,
+		"並列に読み込むファイル数 (0でCPU数、出力の順序は変わらない)")
+	root.PersistentFlags().IntVar(&headLines, "head-lines", 0,
+		"長いファイルの先頭に残す行数 (--tail-lines と併用、0で切り詰めなし)")
+	root.PersistentFlags().IntVar(&tailLines, "tail-lines", 0,
+		"長いファイルの末尾に残す行数 (--head-lines と併用、0で切り詰めなし)")
+	root.PersistentFlags().StringArrayVar(&truncateRules, "truncate", nil,
+		"パターンごとの切り詰め設定 (例: --truncate \"*.log=50:20\"、複数指定可、\"=0:0\"で切り詰めなし)")
+	root.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"エラー以外の診断メッセージを出力しない")
+	root.PersistentFlags().CountVarP(&verbose, "verbose", "v",
+		"診断メッセージを詳しく出力する (-v で除外したファイルと理由、-vv で読み込んだファイルごとの統計)")
+	root.PersistentFlags().StringVar(&logFormat, "log-format", "text",
+		"診断メッセージの形式: text, json")
+	root.PersistentFlags().BoolVar(&strict, "strict", false,
+		"スキップしたバイナリファイルなどの警告もエラーとして扱い、終了コード 3 で終了する")
+	root.PersistentFlags().StringVar(&configFile, "config", "",
+		"設定ファイル (省略時はリポジトリのルートの code2md.yaml / code2md.toml と $XDG_CONFIG_HOME/code2md/config.yaml を読み込む)")
+	root.PersistentFlags().StringVar(&profile, "profile", "",
+		"設定ファイルに定義したプロファイルを適用する (例: --profile review)")
+	root.Flags().StringVarP(&output, "output", "o", "",
+		"出力先のファイル (拡張子が .json の場合は JSON、.xml の場合は XML、それ以外は Markdown で出力する。\"-\" または省略時は標準出力)")
+	root.Flags().BoolVar(&dryRun, "dry-run", false,
+		"出力せずに、対象のファイルとサイズ・トークン数の目安を一覧表示する (code2md ls と同じ)")
+
+	return root
+}
+
+// exitError は、概要を出力済みで、終了コードだけを伝えるエラー
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// finish は、警告とエラーの概要を出力し、終了コードに対応するエラーを返します
+// written は、出力したファイルの数で、0 の場合は出力するファイルがなかったものとします
+func finish(log *slog.Logger, counter *logging.Counter, written int) error {
+	errs, warns := counter.Errors(), counter.Warnings()
+	if errs > 0 {
+		failed := counter.Failed()
+		if len(failed) > maxFailedPaths {
+			failed = append(failed[:maxFailedPaths], fmt.Sprintf("and %d more", len(failed)-maxFailedPaths))
+		}
+		log.Error("finished with errors", "errors", errs, "warnings", warns, "failed", strings.Join(failed, ", "))
+	} else if warns > 0 {
+		log.Info("finished with warnings", "warnings", warns)
+	}
+
+	switch {
+	case written == 0:
+		log.Error("nothing to output")
+		return exitError{exitNothingGathered}
+	case errs > 0:
+		return exitError{exitPartialFailure}
+	}
+	return nil
+}
+
+// newLogger は、--quiet, -v, --log-format, --strict に従ってロガーを作成します
+// 返す Counter で警告とエラーの件数を確認できます。概要の出力には base を使用します
+func newLogger() (base, logger *slog.Logger, counter *logging.Counter, err error) {
+	if quiet && verbose > 0 {
+		return nil, nil, nil, fmt.Errorf("--quiet and --verbose cannot be used together")
+	}
+	base, err = logging.New(os.Stderr, logFormat, logging.Level(quiet, verbose))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("--log-format: %w", err)
+	}
+	counter = logging.NewCounter(base.Handler(), strict)
+	for _, p := range loadedConfigs {
+		base.Debug("loaded config", "path", p, "profile", profile)
+	}
+	return base, slog.New(counter), counter, nil
+}
+
+// exclusiveFlags は、コマンドラインで一方を指定した場合に、設定ファイルのもう一方を無視するフラグ
+var exclusiveFlags = map[string]string{
+	"quiet":   "verbose",
+	"verbose": "quiet",
+}
+
+// applyConfig は、設定ファイルの値を、コマンドラインで指定されていないフラグに適用します
+// --config を指定しない場合は、ユーザーの設定、リポジトリの設定の順に重ねて適用します
+func applyConfig(cmd *cobra.Command, _ []string) error {
+	paths := []string{configFile}
+	if configFile == "" {
+		var err error
+		if paths, err = config.Find("."); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+	var configs []*config.Config
+	for _, p := range paths {
+		c, err := config.Load(p)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		configs = append(configs, c)
+	}
+	values, err := config.Resolve(configs, profile)
+	if err != nil {
+		return fmt.Errorf("--profile: %w", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "config" || key == "profile" {
+			return fmt.Errorf("config: %s cannot be set in a config file", key)
+		}
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil {
+			// 他のサブコマンドのフラグ (--dry-run, --root など) は無視する
+			if !knownFlag(cmd.Root(), key) {
+				return fmt.Errorf("config: unknown option %q", key)
+			}
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+		if other, ok := exclusiveFlags[key]; ok && cmd.Flags().Changed(other) {
+			continue
+		}
+		strs, err := config.Strings(values[key])
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", key, err)
+		}
+		for _, s := range strs {
+			if err := flag.Value.Set(s); err != nil {
+				return fmt.Errorf("config: %s: %w", key, err)
+			}
+		}
+	}
+	loadedConfigs = paths
+	return nil
+}
+
+// knownFlag は、cmd またはそのサブコマンドに name のフラグがあるかを返します
+func knownFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil || cmd.PersistentFlags().Lookup(name) != nil {
+		return true
+	}
+	for _, c := range cmd.Commands() {
+		if knownFlag(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildOptions は、フラグから探索と変換の設定を作成します
+func buildOptions(logger *slog.Logger) (scan.Options, markdown.Options, error) {
+	maxFile, err := scan.ParseSize(maxFileSize)
+	if err != nil {
+		return scan.Options{}, markdown.Options{}, fmt.Errorf("--max-file-size: %w", err)
+	}
+	maxTotal, err := scan.ParseSize(maxTotalSize)
+	if err != nil {
+		return scan.Options{}, markdown.Options{}, fmt.Errorf("--max-total-size: %w", err)
+	}
+
+	opts := scan.Options{
+		UserIgnorePatterns:  ignorePatterns,
+		IncludeDotfiles:     includeDotfiles,
+		ApplyDefaultIgnores: !noDefaultIgnores,
+		AllowSensitive:      allowSensitive,
+		MaxFileSize:         maxFile,
+		MaxTotalSize:        maxTotal,
+		IncludeGenerated:    includeGenerated,
+		SummarizeGenerated:  summarizeGen,
+		Logger:              logger,
+	}
+	if jobs < 0 {
+		return scan.Options{}, markdown.Options{}, fmt.Errorf("--jobs must not be negative")
+	}
+	if headLines < 0 || tailLines < 0 {
+		return scan.Options{}, markdown.Options{}, fmt.Errorf("--head-lines and --tail-lines must not be negative")
+	}
+	lockfiles, err := markdown.ParseLockfileMode(lockfileMode)
+	if err != nil {
+		return scan.Options{}, markdown.Options{}, fmt.Errorf("--lockfiles: %w", err)
+	}
+	mdOpts := markdown.Options{
+		Truncate:            markdown.Truncation{Head: headLines, Tail: tailLines},
+		IncludeGenerated:    includeGenerated,
+		Lockfiles:           lockfiles,
+		BinaryPlaceholders:  binaryMeta,
+		SummarizeGenerated:  summarizeGen,
+		NotebookOutputLines: notebookOutputs,
+		SampleRows:          sampleRows,
+		Jobs:                jobs,
+		Logger:              logger,
+		Normalize: markdown.Normalize{
+			StripBOM:          stripBOM,
+			CRLFToLF:          crlfToLF,
+			TrimTrailingSpace: trimTrailing,
+		},
+	}
+	for _, r := range truncateRules {
+		rule, err := markdown.ParseTruncateRule(r)
+		if err != nil {
+			return scan.Options{}, markdown.Options{}, fmt.Errorf("--truncate: %w", err)
+		}
+		mdOpts.TruncateRules = append(mdOpts.TruncateRules, rule)
+	}
+
+	if mdOpts.Languages, err = loadLanguages(langMapFile, langMaps); err != nil {
+		return scan.Options{}, markdown.Options{}, err
+	}
+	return opts, mdOpts, nil
+}
+
+// runBundle は、対象のファイルを標準出力または -o で指定したファイルに書き出します
+// ファイルへの出力は一時ファイルに書き込んでから置き換えるため、失敗した場合も元のファイルは残ります
+func runBundle(cmd *cobra.Command, args []string) error {
+	base, logger, counter, err := newLogger()
+	if err != nil {
+		return err
+	}
+	opts, mdOpts, err := buildOptions(logger)
+	if err != nil {
+		return err
+	}
 
-	if err := root.Execute(); err != nil {
-		os.Exit(1)
+	files, err := scan.Gather(args, opts)
+	if err != nil {
+		return err
+	}
+	if output == "" || output == "-" {
+		n, err := markdown.PrintCount(os.Stdout, markdownFiles(files), mdOpts)
+		if err != nil {
+			return err
+		}
+		return exitWith(cmd, finish(base, counter, n))
+	}
+
+	files = excludeOutput(logger, files, output)
+	mdOpts.Format = markdown.FormatForPath(output)
+	out, err := createOutput(output)
+	if err != nil {
+		return fmt.Errorf("--output: %w", err)
+	}
+	n, err := markdown.PrintCount(out, markdownFiles(files), mdOpts)
+	if err != nil {
+		out.Abort()
+		return err
+	}
+	if n == 0 {
+		// 出力するファイルがない場合は、出力先を変更しない
+		out.Abort()
+	} else if err := out.Commit(); err != nil {
+		return fmt.Errorf("--output: %w", err)
+	}
+	return exitWith(cmd, finish(base, counter, n))
+}
+
+// markdownFiles は、探索で集めたファイルを変換の入力に変換します
+func markdownFiles(files []scan.File) []markdown.File {
+	out := make([]markdown.File, len(files))
+	for i, f := range files {
+		out[i] = markdown.File{Path: f.Path, Lang: f.Lang, Generated: f.Generated, FS: f.FS, Name: f.Name}
+	}
+	return out
+}
+
+// excludeOutput は、前回の出力を読み込まないよう、出力先のファイルを対象から除きます
+func excludeOutput(log *slog.Logger, files []scan.File, output string) []scan.File {
+	abs, err := filepath.Abs(output)
+	if err != nil {
+		return files
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if f.Path == abs {
+			log.Debug("ignored", "path", output, "reason", "output file")
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// exitWith は、終了コードを伝えるエラーの場合に、cobra がエラーと使い方を表示しないようにします
+func exitWith(cmd *cobra.Command, err error) error {
+	if err != nil {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return err
+}
+
+// loadLanguages は、ファイルとフラグで指定された言語マッピングを読み込みます
+// フラグの指定がファイルより優先されます
+func loadLanguages(file string, maps []string) (lang.Mapping, error) {
+	m := lang.Mapping{}
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("--lang-map-file: %w", err)
+		}
+		defer f.Close()
+		if m, err = lang.LoadMapping(f); err != nil {
+			return nil, fmt.Errorf("--lang-map-file %s: %w", file, err)
+		}
+	}
+	for _, s := range maps {
+		key, tag, err := lang.ParseMapping(s)
+		if err != nil {
+			return nil, fmt.Errorf("--lang-map: %w", err)
+		}
+		m.Set(key, tag)
 	}
+	return m, nil
 }
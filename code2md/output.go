@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// outputFile は、出力先と同じディレクトリの一時ファイルに書き込み、
+// Commit で出力先に置き換えます。Commit するまで出力先のファイルは変更されません
+type outputFile struct {
+	*bufio.Writer
+	f    *os.File
+	path string
+}
+
+// createOutput は、path に書き出すための一時ファイルを作成します
+func createOutput(path string) (*outputFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &outputFile{Writer: bufio.NewWriter(f), f: f, path: path}, nil
+}
+
+// Commit は、書き込んだ内容を出力先に置き換えます
+// 出力先のファイルが既にある場合は、そのパーミッションを引き継ぎます
+func (o *outputFile) Commit() error {
+	err := o.Flush()
+	if err == nil {
+		err = o.f.Sync()
+	}
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		var mode fs.FileMode = 0644
+		if info, serr := os.Stat(o.path); serr == nil {
+			mode = info.Mode().Perm()
+		}
+		err = os.Chmod(o.f.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(o.f.Name(), o.path)
+	}
+	if err != nil {
+		os.Remove(o.f.Name())
+	}
+	return err
+}
+
+// Abort は、出力先を変更せずに一時ファイルを削除します
+func (o *outputFile) Abort() {
+	o.f.Close()
+	os.Remove(o.f.Name())
+}
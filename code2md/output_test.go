@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/your-org/code2md/internal/scan"
+)
+
+// writeFile は、テスト用のファイルを作成します
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+	// umask の影響を受けないよう、パーミッションを設定し直す
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile は、ファイルの内容を返します
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	return string(b)
+}
+
+// assertNoTempFiles は、dir に一時ファイルが残っていないことを確認します
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	tmps, _ := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if len(tmps) > 0 {
+		t.Errorf("一時ファイルが残っています: %v", tmps)
+	}
+}
+
+// Commit するまで出力先が変更されず、Commit で置き換わることを検証
+func TestOutputFileCommit(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		perm     os.FileMode
+		expected os.FileMode
+	}{
+		{"新しいファイル", false, 0, 0644},
+		{"既存のファイルのパーミッションを引き継ぐ", true, 0600, 0600},
+		{"実行権限も引き継ぐ", true, 0755, 0755},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "bundle.md")
+			if tt.existing {
+				writeFile(t, path, "old\n", tt.perm)
+			}
+
+			out, err := createOutput(path)
+			if err != nil {
+				t.Fatalf("createOutput() エラー: %v", err)
+			}
+			io.WriteString(out, "new\n")
+			out.Flush()
+			if tt.existing && readFile(t, path) != "old\n" {
+				t.Errorf("Commit の前に出力先が変更されています")
+			}
+			if _, err := os.Stat(path); !tt.existing && err == nil {
+				t.Errorf("Commit の前に出力先が作成されています")
+			}
+
+			if err := out.Commit(); err != nil {
+				t.Fatalf("Commit() エラー: %v", err)
+			}
+			if got := readFile(t, path); got != "new\n" {
+				t.Errorf("出力先の内容 = %q, expected %q", got, "new\n")
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.expected {
+				t.Errorf("パーミッション = %v, expected %v", info.Mode().Perm(), tt.expected)
+			}
+			assertNoTempFiles(t, dir)
+		})
+	}
+}
+
+// Abort した場合は出力先を変更せず、一時ファイルを削除することを検証
+func TestOutputFileAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.md")
+	writeFile(t, path, "old\n", 0600)
+
+	out, err := createOutput(path)
+	if err != nil {
+		t.Fatalf("createOutput() エラー: %v", err)
+	}
+	io.WriteString(out, strings.Repeat("partial\n", 10000))
+	out.Abort()
+
+	if got := readFile(t, path); got != "old\n" {
+		t.Errorf("Abort で出力先が変更されています: %q", got)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+// 出力先のファイルを対象から除くことを検証
+func TestExcludeOutput(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "bundle.md")
+	files := []scan.File{
+		{Path: filepath.Join(dir, "main.go")},
+		{Path: output},
+		{Path: filepath.Join(dir, "sub", "bundle.md")},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	got := excludeOutput(logger, files, output)
+	if len(got) != 2 || got[0].Path != files[0].Path || got[1].Path != filepath.Join(dir, "sub", "bundle.md") {
+		t.Errorf("excludeOutput() = %+v", got)
+	}
+}
+
+// -o を指定した実行で、前回の出力を読み込まず、出力するファイルがない場合は出力先を変更しないことを検証
+func TestRunBundleOutput(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "bundle.md")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n", 0644)
+	writeFile(t, output, "previous bundle\n", 0644)
+
+	if err := executeBundle(t, []string{dir, "-o", output, "-q"}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	got := readFile(t, output)
+	if !strings.Contains(got, "package main\n") || strings.Contains(got, "previous bundle") {
+		t.Errorf("出力の内容が正しくありません:\n%s", got)
+	}
+
+	// バイナリファイルのみの場合は出力するファイルがない
+	empty := t.TempDir()
+	writeFile(t, filepath.Join(empty, "logo.png"), "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", 0644)
+	err := executeBundle(t, []string{empty, "-o", output, "-q"})
+	var exit exitError
+	if !errors.As(err, &exit) || exit.code != exitNothingGathered {
+		t.Errorf("エラー = %v, expected exit status %d", err, exitNothingGathered)
+	}
+	if readFile(t, output) != got {
+		t.Errorf("出力するファイルがない場合に出力先が変更されています")
+	}
+	assertNoTempFiles(t, dir)
+}
+
+// executeBundle は、args でコマンドを実行します (診断メッセージは出力しません)
+func executeBundle(t *testing.T, args []string) error {
+	t.Helper()
+	root := newRootCommand()
+	root.SetArgs(append(args, "--config", writeEmptyConfig(t)))
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	return root.Execute()
+}
+
+// writeEmptyConfig は、ユーザーの設定ファイルを読み込まないよう、空の設定ファイルを作成します
+func writeEmptyConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "code2md.yaml")
+	writeFile(t, path, "", 0644)
+	return path
+}
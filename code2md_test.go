@@ -0,0 +1,220 @@
+package code2md
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/your-org/code2md/internal/markdown"
+	"github.com/your-org/code2md/internal/scan"
+)
+
+// writeFiles は、テスト用のファイルを作成してディレクトリのパスを返します
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("ファイル作成に失敗: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestBundle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.go":             "package main\r\n\r\nfunc main() {}\r\n",
+		"README.md":           "# タイトル\n",
+		"node_modules/x/a.js": "module.exports = 1\n",
+		".env":                "SECRET=1\n",
+		"legacy/hello.c":      "// \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n",
+		"templates/page.tmpl": "{{ .Title }}\n",
+		"assets/logo.png":     "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	})
+
+	var log strings.Builder
+	res, err := Bundle(context.Background(), []string{dir}, Options{
+		Languages: map[string]string{".tmpl": "gotemplate"},
+		Log:       &log,
+	})
+	if err != nil {
+		t.Fatalf("Bundle() エラー: %v", err)
+	}
+
+	got := map[string]File{}
+	for _, f := range res.Files {
+		got[filepath.Base(f.AbsPath)] = f
+	}
+	if len(got) != 4 {
+		t.Fatalf("Bundle() = %d 件, expected 4 件: %+v", len(got), res.Files)
+	}
+
+	// ゼロ値の Options は CLI のデフォルトと同じ (CRLF を LF に変換)
+	if f := got["main.go"]; f.Lang != "go" || f.Content != "package main\n\nfunc main() {}\n" {
+		t.Errorf("main.go = %+v", f)
+	}
+	if f := got["hello.c"]; f.Encoding != "Shift_JIS" || f.Content != "// こんにちは\n" {
+		t.Errorf("hello.c = %+v", f)
+	}
+	if f := got["page.tmpl"]; f.Lang != "gotemplate" {
+		t.Errorf("page.tmpl = %+v", f)
+	}
+	if f := got["README.md"]; f.Stats != (Stats{Lines: 1, Words: 2, Chars: 7}) {
+		t.Errorf("README.md = %+v", f)
+	}
+
+	var total Stats
+	for _, f := range res.Files {
+		total.Lines += f.Stats.Lines
+		total.Words += f.Stats.Words
+		total.Chars += f.Stats.Chars
+	}
+	if res.Total != total {
+		t.Errorf("Total = %+v, expected %+v", res.Total, total)
+	}
+
+	// 診断メッセージは Log に出力される
+	if !strings.Contains(log.String(), "logo.png") || !strings.Contains(log.String(), "node_modules") {
+		t.Errorf("Log に除外の理由が出力されていません:\n%s", log.String())
+	}
+}
+
+// RenderMarkdown の出力が CLI (markdown.Print) と一致することを検証
+func TestRenderMarkdown(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a/main.go":   "package main\n",
+		"b/notes.txt": "hello\n\n\n",
+		"c/hello.c":   "// \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n",
+		"c/nb.ipynb":  `{"nbformat": 4, "metadata": {}, "cells": [{"cell_type": "code", "source": ["print(1)"], "outputs": []}]}`,
+		"d/logo.png":  "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"e/long.log":  strings.Repeat("line\n", 30),
+	})
+	opt := Options{BinaryPlaceholders: true, Truncate: Truncation{Head: 2, Tail: 2}}
+
+	res, err := Bundle(context.Background(), []string{dir}, opt)
+	if err != nil {
+		t.Fatalf("Bundle() エラー: %v", err)
+	}
+	var got strings.Builder
+	if err := RenderMarkdown(&got, res); err != nil {
+		t.Fatalf("RenderMarkdown() エラー: %v", err)
+	}
+
+	mdOpts, err := opt.markdownOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := scan.Gather([]string{dir}, opt.scanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected strings.Builder
+	if err := markdown.Print(&expected, markdownFiles(files), mdOpts); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.String() != expected.String() {
+		t.Errorf("RenderMarkdown() =\n%s\nexpected:\n%s", got.String(), expected.String())
+	}
+}
+
+// 呼び出し側が作成・編集した File でも、内容に合わせた区切りで書き出すことを検証
+func TestRenderMarkdownFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"バッククォートなし", "hello\n", "```text:notes.md\nhello\n```\n\n"},
+		{"コードブロックを含む", "```go\nx\n```\n", "````text:notes.md\n```go\nx\n```\n````\n\n"},
+		{"長い並びを含む", "  `````\n", "``````text:notes.md\n  `````\n``````\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &Result{Files: []File{{Path: "notes.md", Lang: "text", Content: tt.content}}}
+			var got strings.Builder
+			if err := RenderMarkdown(&got, res); err != nil {
+				t.Fatalf("RenderMarkdown() エラー: %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("RenderMarkdown() = %q, expected %q", got.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestBundleCanceled(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.go": "package main\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Bundle(ctx, []string{dir}, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Bundle() = %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestBundleInvalidOptions(t *testing.T) {
+	for _, opt := range []Options{
+		{Lockfiles: "all"},
+		{Truncate: Truncation{Head: -1}},
+		{TruncateRules: []TruncateRule{{Pattern: "*.log", Truncation: Truncation{Tail: -1}}}},
+	} {
+		if _, err := Bundle(context.Background(), []string{"."}, opt); err == nil {
+			t.Errorf("Bundle(%+v) はエラーを返すべきです", opt)
+		}
+	}
+}
+
+// BundleFS が fs.FS 上のファイルを FS 内のパスで変換することを検証
+func TestBundleFS(t *testing.T) {
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewGray(image.Rect(0, 0, 16, 8))); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"src/main.go":     {Data: []byte("package main\r\n")},
+		"src/logo.png":    {Data: logo.Bytes()},
+		"docs/guide.md":   {Data: []byte("# ガイド\n")},
+		"src/.env":        {Data: []byte("SECRET=1\n")},
+		"vendor/lib/a.go": {Data: []byte("package lib\n")},
+	}
+
+	res, err := BundleFS(context.Background(), fsys, []string{"src"}, Options{BinaryPlaceholders: true})
+	if err != nil {
+		t.Fatalf("BundleFS() エラー: %v", err)
+	}
+	if len(res.Files) != 2 {
+		t.Fatalf("BundleFS() = %d 件, expected 2 件: %+v", len(res.Files), res.Files)
+	}
+	if f := res.Files[0]; f.Path != "src/logo.png" || f.AbsPath != "src/logo.png" || !f.Binary || !strings.Contains(f.Content, "dimensions: 16x8") {
+		t.Errorf("logo.png = %+v", f)
+	}
+	if f := res.Files[1]; f.Path != "src/main.go" || f.Lang != "go" || f.Content != "package main\n" {
+		t.Errorf("main.go = %+v", f)
+	}
+
+	// roots を省略した場合は FS 全体が対象
+	res, err = BundleFS(context.Background(), fsys, nil, Options{})
+	if err != nil {
+		t.Fatalf("BundleFS() エラー: %v", err)
+	}
+	var paths []string
+	for _, f := range res.Files {
+		paths = append(paths, f.Path)
+	}
+	if strings.Join(paths, ",") != "docs/guide.md,src/main.go" {
+		t.Errorf("BundleFS() = %v", paths)
+	}
+}
@@ -3,8 +3,11 @@ module github.com/your-org/code2md
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/bmatcuk/doublestar/v4 v4.6.1
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,256 @@
+// Package config は、code2md.yaml / code2md.toml の設定ファイルを読み込みます
+//
+// 設定ファイルには、コマンドラインのフラグと同じ名前のキーでデフォルト値と
+// 名前付きのプロファイルを記述します
+//
+//	defaults:
+//	  ignore: ["*.md", testdata]
+//	  max-file-size: 1MB
+//	profiles:
+//	  review:
+//	    lockfiles: summary
+//	    head-lines: 200
+//	  api-only:
+//	    ignore: ["*_test.go", web]
+//	    lang-map: {".tmpl": gotemplate}
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// リポジトリのルートで探す設定ファイルの名前 (この順に探します)
+var repoFiles = []string{"code2md.yaml", "code2md.yml", "code2md.toml"}
+
+// ユーザーの設定ディレクトリ ($XDG_CONFIG_HOME/code2md) で探す設定ファイルの名前
+var userFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// PathKeys は、値をファイルのパスとして扱うキー
+// 相対パスは設定ファイルのあるディレクトリからのパスとして解決します
+// 空の値と標準出力を表す "-" はそのまま残します
+var PathKeys = []string{"lang-map-file", "output"}
+
+// Values は、フラグ名と値の対応
+// 値には文字列・数値・真偽値、複数指定できるフラグにはそれらのリスト、
+// "キー=値" 形式のフラグ (lang-map, truncate) には対応表を指定できます
+type Values map[string]any
+
+// Config は、1つの設定ファイルの内容
+type Config struct {
+	Path     string            `yaml:"-" toml:"-"` // 読み込んだファイルのパス
+	Defaults Values            `yaml:"defaults" toml:"defaults"`
+	Profiles map[string]Values `yaml:"profiles" toml:"profiles"`
+}
+
+// Find は、dir を含むリポジトリのルートとユーザーの設定ディレクトリから設定ファイルを探し、
+// 優先度の低い順 (ユーザーの設定、リポジトリの設定) に返します
+// dir が git のリポジトリに含まれない場合は、dir をリポジトリのルートとして扱います
+func Find(dir string) ([]string, error) {
+	var paths []string
+	if userDir, err := os.UserConfigDir(); err == nil {
+		p, err := findIn(filepath.Join(userDir, "code2md"), userFiles)
+		if err != nil {
+			return nil, err
+		}
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	p, err := findIn(repoRoot(dir), repoFiles)
+	if err != nil {
+		return nil, err
+	}
+	if p != "" {
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// findIn は、dir にある設定ファイルを返します (見つからない場合は空文字を返します)
+// 複数の形式のファイルがある場合は、どちらを使うか曖昧なためエラーを返します
+func findIn(dir string, names []string) (string, error) {
+	var found []string
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("multiple config files found: %s", strings.Join(found, ", "))
+}
+
+// repoRoot は、dir から親をたどって .git のあるディレクトリを返します
+// 見つからない場合は dir を返します
+func repoRoot(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return abs
+		}
+	}
+}
+
+// Load は、設定ファイルを読み込みます
+// 拡張子が .toml の場合は TOML、それ以外は YAML として読み込みます
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c *Config
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		c, err = decodeTOML(data)
+	} else {
+		c, err = decodeYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.Path = path
+
+	// パスの値を設定ファイルのディレクトリから解決する
+	dir := filepath.Dir(path)
+	c.Defaults.resolvePaths(dir)
+	for _, v := range c.Profiles {
+		v.resolvePaths(dir)
+	}
+	return c, nil
+}
+
+func decodeYAML(data []byte) (*Config, error) {
+	c := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return c, nil
+}
+
+func decodeTOML(data []byte) (*Config, error) {
+	c := &Config{}
+	md, err := toml.Decode(string(data), c)
+	if err != nil {
+		return nil, err
+	}
+	// 値の対応表の中のキーも Undecoded に含まれるため、最上位のキーのみ確認する
+	for _, key := range md.Undecoded() {
+		if key[0] != "defaults" && key[0] != "profiles" {
+			return nil, fmt.Errorf("unknown field %q (expected defaults or profiles)", key[0])
+		}
+	}
+	return c, nil
+}
+
+func (v Values) resolvePaths(dir string) {
+	for _, key := range PathKeys {
+		if p, ok := v[key].(string); ok && p != "" && p != "-" && !filepath.IsAbs(p) {
+			v[key] = filepath.Join(dir, p)
+		}
+	}
+}
+
+// Resolve は、優先度の低い順に並んだ設定を重ね、profile を適用した値を返します
+// 値は、すべての設定のデフォルト値、プロファイルの値の順に上書きされます
+// profile が空でなく、どの設定にも存在しない場合はエラーを返します
+func Resolve(configs []*Config, profile string) (Values, error) {
+	v := Values{}
+	for _, c := range configs {
+		for key, value := range c.Defaults {
+			v[key] = value
+		}
+	}
+	if profile == "" {
+		return v, nil
+	}
+
+	found := false
+	for _, c := range configs {
+		if p, ok := c.Profiles[profile]; ok {
+			found = true
+			for key, value := range p {
+				v[key] = value
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(profileNames(configs), ", "))
+	}
+	return v, nil
+}
+
+// profileNames は、設定に含まれるプロファイルの名前を辞書順に返します
+func profileNames(configs []*Config) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, c := range configs {
+		for name := range c.Profiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Strings は、設定の値をフラグに渡す文字列に変換します
+// リストは要素ごとに、対応表は "キー=値" の形式でキーの辞書順に返します
+func Strings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case Values:
+		// YAML の対応表は、親と同じ Values として読み込まれる
+		return Strings(map[string]any(v))
+	case []any:
+		var s []string
+		for _, e := range v {
+			switch e.(type) {
+			case []any, map[string]any, Values:
+				return nil, fmt.Errorf("nested lists and tables are not supported")
+			}
+			s = append(s, fmt.Sprint(e))
+		}
+		return s, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		s := make([]string, 0, len(keys))
+		for _, k := range keys {
+			s = append(s, fmt.Sprintf("%s=%v", k, v[k]))
+		}
+		return s, nil
+	}
+	return []string{fmt.Sprint(value)}, nil
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `
+defaults:
+  ignore: ["*.md", testdata]
+  max-file-size: 1MB
+  lang-map-file: langs.yaml
+profiles:
+  review:
+    lockfiles: summary
+    head-lines: 200
+    include-generated: true
+  api-only:
+    ignore: ["*_test.go"]
+    lang-map: {".tmpl": gotemplate, Jenkinsfile.ci: groovy}
+`
+
+const tomlConfig = `
+[defaults]
+ignore = ["*.md", "testdata"]
+max-file-size = "1MB"
+lang-map-file = "langs.yaml"
+
+[profiles.review]
+lockfiles = "summary"
+head-lines = 200
+include-generated = true
+
+[profiles.api-only]
+ignore = ["*_test.go"]
+lang-map = { ".tmpl" = "gotemplate", "Jenkinsfile.ci" = "groovy" }
+`
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("ディレクトリ作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+}
+
+// flatten は、Resolve の結果をフラグに渡す文字列に変換します
+func flatten(t *testing.T, v Values) map[string][]string {
+	t.Helper()
+	m := map[string][]string{}
+	for key, value := range v {
+		s, err := Strings(value)
+		if err != nil {
+			t.Fatalf("Strings(%v) エラー: %v", value, err)
+		}
+		m[key] = s
+	}
+	return m
+}
+
+// YAML と TOML のどちらからも同じ値が得られることを検証
+func TestLoadAndResolve(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"code2md.yaml", "code2md.toml"} {
+		t.Run(name, func(t *testing.T) {
+			content := yamlConfig
+			if strings.HasSuffix(name, ".toml") {
+				content = tomlConfig
+			}
+			path := filepath.Join(tempDir, name)
+			writeConfig(t, path, content)
+
+			c, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() エラー: %v", err)
+			}
+			langs := filepath.Join(tempDir, "langs.yaml")
+
+			tests := []struct {
+				profile  string
+				expected map[string][]string
+			}{
+				{"", map[string][]string{
+					"ignore":        {"*.md", "testdata"},
+					"max-file-size": {"1MB"},
+					"lang-map-file": {langs},
+				}},
+				{"review", map[string][]string{
+					"ignore":            {"*.md", "testdata"},
+					"max-file-size":     {"1MB"},
+					"lang-map-file":     {langs},
+					"lockfiles":         {"summary"},
+					"head-lines":        {"200"},
+					"include-generated": {"true"},
+				}},
+				{"api-only", map[string][]string{
+					"ignore":        {"*_test.go"},
+					"max-file-size": {"1MB"},
+					"lang-map-file": {langs},
+					"lang-map":      {".tmpl=gotemplate", "Jenkinsfile.ci=groovy"},
+				}},
+			}
+			for _, tt := range tests {
+				v, err := Resolve([]*Config{c}, tt.profile)
+				if err != nil {
+					t.Fatalf("Resolve(%q) エラー: %v", tt.profile, err)
+				}
+				if got := flatten(t, v); !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("Resolve(%q) = %v, expected %v", tt.profile, got, tt.expected)
+				}
+			}
+
+			if _, err := Resolve([]*Config{c}, "missing"); err == nil || !strings.Contains(err.Error(), "api-only, review") {
+				t.Errorf("存在しないプロファイルは利用できる名前とともにエラーを返すべきです: %v", err)
+			}
+		})
+	}
+}
+
+// パスの値のうち、標準出力を表す "-" と空の値は解決しないことを検証
+func TestLoadKeepsStdoutPath(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "code2md.yaml")
+	writeConfig(t, path, `
+defaults:
+  output: "-"
+  lang-map-file: ""
+profiles:
+  bundle:
+    output: out/bundle.md
+`)
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() エラー: %v", err)
+	}
+	if got := c.Defaults["output"]; got != "-" {
+		t.Errorf("output = %v, expected -", got)
+	}
+	if got := c.Defaults["lang-map-file"]; got != "" {
+		t.Errorf("lang-map-file = %v, expected empty", got)
+	}
+	if got, expected := c.Profiles["bundle"]["output"], filepath.Join(tempDir, "out/bundle.md"); got != expected {
+		t.Errorf("output = %v, expected %v", got, expected)
+	}
+}
+
+// 後から指定した設定の値が優先されることを検証
+func TestResolveOrder(t *testing.T) {
+	user := &Config{
+		Defaults: Values{"jobs": 4, "lockfiles": "omit"},
+		Profiles: map[string]Values{"review": {"head-lines": 100, "tail-lines": 10}},
+	}
+	repo := &Config{
+		Defaults: Values{"lockfiles": "summary"},
+		Profiles: map[string]Values{"review": {"head-lines": 200}},
+	}
+	v, err := Resolve([]*Config{user, repo}, "review")
+	if err != nil {
+		t.Fatalf("Resolve() エラー: %v", err)
+	}
+	expected := Values{"jobs": 4, "lockfiles": "summary", "head-lines": 200, "tail-lines": 10}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Resolve() = %v, expected %v", v, expected)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	tests := []struct {
+		name, content string
+	}{
+		{"unknown.yaml", "default:\n  ignore: [a]\n"},
+		{"unknown.toml", "[default]\nignore = [\"a\"]\n"},
+		{"broken.yaml", "defaults: [\n"},
+		{"broken.toml", "[defaults\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(tempDir, tt.name)
+		writeConfig(t, path, tt.content)
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("Load(%s) はファイル名を含むエラーを返すべきです: %v", tt.name, err)
+		}
+	}
+
+	// 空のファイルは何も指定しない設定として扱う
+	empty := filepath.Join(tempDir, "empty.yaml")
+	writeConfig(t, empty, "")
+	if c, err := Load(empty); err != nil || len(c.Defaults) != 0 {
+		t.Errorf("Load(empty.yaml) = (%v, %v)", c, err)
+	}
+
+	if _, err := Strings([]any{[]any{"a"}}); err == nil {
+		t.Error("入れ子のリストはエラーを返すべきです")
+	}
+}
+
+// リポジトリのルートとユーザーの設定ディレクトリから設定ファイルを探すことを検証
+func TestFind(t *testing.T) {
+	tempDir := t.TempDir()
+	home := filepath.Join(tempDir, "home")
+	repo := filepath.Join(tempDir, "repo")
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("HOME", home)
+
+	writeConfig(t, filepath.Join(repo, ".git/HEAD"), "ref: refs/heads/main")
+	writeConfig(t, filepath.Join(repo, "src/pkg/a.go"), "package pkg")
+
+	// 設定ファイルがない場合
+	got, err := Find(filepath.Join(repo, "src/pkg"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("Find() = (%v, %v), expected none", got, err)
+	}
+
+	userConfig := filepath.Join(home, "code2md/config.toml")
+	repoConfig := filepath.Join(repo, "code2md.yaml")
+	writeConfig(t, userConfig, "")
+	writeConfig(t, repoConfig, "")
+	writeConfig(t, filepath.Join(repo, "src/code2md.yaml"), "") // リポジトリのルート以外は参照しない
+
+	got, err = Find(filepath.Join(repo, "src/pkg"))
+	if err != nil {
+		t.Fatalf("Find() エラー: %v", err)
+	}
+	if expected := []string{userConfig, repoConfig}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Find() = %v, expected %v", got, expected)
+	}
+
+	// 同じ場所に複数の形式の設定ファイルがある場合はエラー
+	writeConfig(t, filepath.Join(repo, "code2md.toml"), "")
+	if _, err := Find(repo); err == nil {
+		t.Error("複数の設定ファイルがある場合はエラーを返すべきです")
+	}
+}
@@ -0,0 +1,108 @@
+package lang
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// 内容から判定する際に参照する先頭の行数
+const headLines = 5
+
+var (
+	// Emacs のモード指定 (-*- mode: python -*- または -*- python -*-)
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([\w+-]+)|([\w+-]+))\s*(?:;.*)?-\*-`)
+	// Vim のモードライン (vim: set ft=python : または vim: filetype=python)
+	vimModeline = regexp.MustCompile(`\b(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+-]+)`)
+	// インタプリタ名末尾のバージョン番号 (python3.11 → python)
+	interpreterVersion = regexp.MustCompile(`[\d.]+$`)
+)
+
+// 先頭の内容による判定ルール
+var contentPrefixes = []struct {
+	prefix string
+	lang   string
+}{
+	{"<?php", "php"},
+	{"<?xml", "xml"},
+	{"<!doctype html", "html"},
+	{"<html", "html"},
+}
+
+// detectContent は、シバン、Emacs/Vim のモードライン、先頭の内容から言語を推測します
+func detectContent(head []byte) string {
+	lines := strings.SplitN(string(head), "\n", headLines+1)
+	if len(lines) > headLines {
+		lines = lines[:headLines]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	// シバン
+	if strings.HasPrefix(lines[0], "#!") {
+		if lang := interpreterMap[interpreter(lines[0])]; lang != "" {
+			return lang
+		}
+	}
+
+	// モードライン
+	for _, line := range lines {
+		if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			if lang := modeName(m[1] + m[2]); lang != "" {
+				return lang
+			}
+		}
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			if lang := modeName(m[1]); lang != "" {
+				return lang
+			}
+		}
+	}
+
+	// 先頭の内容
+	trimmed := bytes.ToLower(bytes.TrimSpace(head))
+	for _, c := range contentPrefixes {
+		if bytes.HasPrefix(trimmed, []byte(c.prefix)) {
+			return c.lang
+		}
+	}
+	return ""
+}
+
+// interpreter は、シバン行からインタプリタ名を取り出します
+// (#!/usr/bin/env -S python3 -u → python, #!/bin/bash → bash)
+func interpreter(shebang string) string {
+	fields := strings.Fields(strings.TrimPrefix(shebang, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	name := path.Base(fields[0])
+	if name == "env" {
+		name = ""
+		for _, f := range fields[1:] {
+			// env のオプションと環境変数の指定は読み飛ばす
+			if strings.HasPrefix(f, "-") || strings.Contains(f, "=") {
+				continue
+			}
+			name = path.Base(f)
+			break
+		}
+	}
+
+	if _, ok := interpreterMap[name]; ok {
+		return name
+	}
+	return interpreterVersion.ReplaceAllString(name, "")
+}
+
+// modeName は、エディタのモード名を言語タグに変換します
+func modeName(mode string) string {
+	mode = strings.ToLower(mode)
+	if lang, ok := aliasMap[mode]; ok {
+		return lang
+	}
+	return mode
+}
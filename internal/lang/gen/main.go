@@ -0,0 +1,165 @@
+// gen は、languages.yml から言語判定用のテーブル (table_gen.go) を生成します
+//
+// internal/lang ディレクトリで `go generate` から実行されます
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	inputFile  = flag.String("in", "languages.yml", "言語定義のYAMLファイル")
+	outputFile = flag.String("out", "table_gen.go", "生成するGoファイル")
+)
+
+// language は、languages.yml の1言語分の定義
+type language struct {
+	Tag          string   `yaml:"tag"`
+	Aliases      []string `yaml:"aliases"`
+	Extensions   []string `yaml:"extensions"`
+	Filenames    []string `yaml:"filenames"`
+	Patterns     []string `yaml:"patterns"`
+	Interpreters []string `yaml:"interpreters"`
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gen: ")
+	flag.Parse()
+
+	data, err := os.ReadFile(*inputFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var languages map[string]language
+	if err := yaml.Unmarshal(data, &languages); err != nil {
+		log.Fatalf("%s: %v", *inputFile, err)
+	}
+
+	exts := map[string]string{}
+	files := map[string]string{}
+	patterns := map[string]string{}
+	interpreters := map[string]string{}
+	aliases := map[string]string{}
+
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		l := languages[name]
+		tag := l.Tag
+		if tag == "" {
+			tag = strings.ToLower(name)
+		}
+
+		for _, e := range l.Extensions {
+			if !strings.HasPrefix(e, ".") {
+				log.Fatalf("%s: extension %q must start with '.'", name, e)
+			}
+			add(exts, strings.ToLower(e), tag, name, "extension")
+		}
+		for _, f := range l.Filenames {
+			add(files, strings.ToLower(f), tag, name, "filename")
+		}
+		for _, p := range l.Patterns {
+			if !strings.ContainsAny(p, "*?[") {
+				log.Fatalf("%s: pattern %q has no wildcard (use filenames instead)", name, p)
+			}
+			if _, err := path.Match(p, ""); err != nil {
+				log.Fatalf("%s: pattern %q: %v", name, p, err)
+			}
+			add(patterns, strings.ToLower(p), tag, name, "pattern")
+		}
+		for _, i := range l.Interpreters {
+			add(interpreters, i, tag, name, "interpreter")
+		}
+
+		// 別名には言語名 (空白をハイフンにしたものを含む) と言語タグ自体も含める
+		// 異なる言語の間で重複した場合は、言語名の辞書順で先の言語を優先 (languages.yml での定義順ではない)
+		lower := strings.ToLower(name)
+		for _, a := range append([]string{lower, strings.ReplaceAll(lower, " ", "-"), tag}, l.Aliases...) {
+			if _, ok := aliases[strings.ToLower(a)]; !ok {
+				aliases[strings.ToLower(a)] = tag
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by go run ./gen from %s; DO NOT EDIT.\n\n", filepath.Base(*inputFile))
+	fmt.Fprintf(&buf, "package lang\n\n")
+	writeMap(&buf, "extMap", "拡張子 (小文字) から言語タグへのマッピング", exts)
+	writeMap(&buf, "fileMap", "ファイル名 (小文字) から言語タグへのマッピング", files)
+	writePatterns(&buf, "patternRules", "ファイル名のパターン (小文字) と言語タグ (具体的なものから順に照合)", patterns)
+	writeMap(&buf, "interpreterMap", "シバンのインタプリタ名から言語タグへのマッピング", interpreters)
+	writeMap(&buf, "aliasMap", "言語名・別名 (小文字) から言語タグへのマッピング", aliases)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("format: %v", err)
+	}
+	if err := os.WriteFile(*outputFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// add は、キーの重複を検出しながらマッピングに追加します
+func add(m map[string]string, key, tag, name, kind string) {
+	if prev, ok := m[key]; ok && prev != tag {
+		log.Fatalf("%s: %s %q is already mapped to %q", name, kind, key, prev)
+	}
+	m[key] = tag
+}
+
+// writeMap は、マッピングをキー順に Go のソースとして書き出します
+func writeMap(buf *bytes.Buffer, name, comment string, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(buf, "// %s\n", comment)
+	fmt.Fprintf(buf, "var %s = map[string]string{\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "\t%q: %q,\n", k, m[k])
+	}
+	fmt.Fprintf(buf, "}\n\n")
+}
+
+// writePatterns は、パターンを具体的なもの (ワイルドカード以外の文字が多いもの) から順に書き出します
+func writePatterns(buf *bytes.Buffer, name, comment string, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	literal := func(p string) int {
+		return len(p) - strings.Count(p, "*") - strings.Count(p, "?")
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if li, lj := literal(keys[i]), literal(keys[j]); li != lj {
+			return li > lj
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(buf, "// %s\n", comment)
+	fmt.Fprintf(buf, "var %s = []patternRule{\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "\t{%q, %q},\n", k, m[k])
+	}
+	fmt.Fprintf(buf, "}\n\n")
+}
@@ -1,74 +1,57 @@
 package lang
 
+// extMap, fileMap, patternRules, interpreterMap, aliasMap は languages.yml から生成されます
+//go:generate go run ./gen
+
 import (
 	"path/filepath"
 	"strings"
 )
 
-// 拡張子から言語を推測するためのマッピング
-var extMap = map[string]string{
-	".py":         "python",
-	".pyw":        "python",
-	".js":         "javascript",
-	".mjs":        "javascript",
-	".cjs":        "javascript",
-	".html":       "html",
-	".htm":        "html",
-	".css":        "css",
-	".md":         "markdown",
-	".json":       "json",
-	".yaml":       "yaml",
-	".yml":        "yaml",
-	".toml":       "toml",
-	".sh":         "bash",
-	".bash":       "bash",
-	".zsh":        "zsh",
-	".java":       "java",
-	".c":          "c",
-	".h":          "c",
-	".cpp":        "cpp",
-	".hpp":        "cpp",
-	".cc":         "cpp",
-	".hh":         "cpp",
-	".cs":         "csharp",
-	".go":         "go",
-	".rs":         "rust",
-	".php":        "php",
-	".rb":         "ruby",
-	".swift":      "swift",
-	".kt":         "kotlin",
-	".scala":      "scala",
-	".R":          "r",
-	".lock":       "toml",
-	".sql":        "sql",
-	".xml":        "xml",
-	".dockerfile": "dockerfile",
-}
-
-// ファイル名から言語を推測するためのマッピング
-var fileMap = map[string]string{
-	"dockerfile": "dockerfile",
-	".gitignore": "gitignore",
-	"makefile":   "makefile",
-	"Makefile":   "makefile",
+// patternRule は、ファイル名のワイルドカードパターンと言語タグの対応
+type patternRule struct {
+	pattern string
+	lang    string
 }
 
-// Detect は、ファイルパスから言語を推測します
-func Detect(path string) string {
+// Detect は、ファイルパスと内容の先頭部分から言語を推測します
+// ファイル名の完全一致、ファイル名のパターン (Dockerfile.* など)、拡張子の順に照合し、
+// 拡張子は "types.d.ts" なら ".d.ts"、".ts" のように長いものから試します。
+// いずれでも判定できない場合は、head (ファイル先頭の数行) のシバン、
+// Emacs/Vim のモードライン、先頭の内容を参照します。head は nil でも構いません
+func Detect(path string, head []byte) string {
 	// ファイル名で検索
-	filename := filepath.Base(path)
+	filename := strings.ToLower(filepath.Base(path))
 	if lang, ok := fileMap[filename]; ok {
 		return lang
 	}
 
-	// 拡張子で検索
-	ext := filepath.Ext(path)
-	if ext != "" {
-		if lang, ok := extMap[strings.ToLower(ext)]; ok {
+	// ファイル名のパターンで検索
+	for _, r := range patternRules {
+		if ok, _ := filepath.Match(r.pattern, filename); ok {
+			return r.lang
+		}
+	}
+
+	// 拡張子で検索 (長いものから順に)
+	for _, ext := range extensions(filename) {
+		if lang, ok := extMap[ext]; ok {
 			return lang
 		}
 	}
 
-	// 一致するものがなければ空文字を返す
-	return ""
+	// 内容から推測 (一致するものがなければ空文字を返す)
+	return detectContent(head)
+}
+
+// extensions は、ファイル名に含まれる拡張子の候補を長いものから順に返します
+// ("foo.test.tsx" → ".test.tsx", ".tsx")
+func extensions(filename string) []string {
+	var exts []string
+	for i := 0; i < len(filename); i++ {
+		if filename[i] == '.' {
+			exts = append(exts, filename[i:])
+		}
+	}
+	return exts
 }
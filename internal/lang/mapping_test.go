@@ -0,0 +1,80 @@
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path     string
+		head     string
+		expected string
+	}{
+		{"main.go", "", "go"},
+		{"src/app.PY", "", "python"},
+		{"Makefile", "", "makefile"},
+		{"README", "", ""},
+		{"analysis.R", "", "r"},
+		{"src/App.tsx", "", "tsx"},
+		{"src/index.ts", "", "typescript"},
+		{"components/Button.vue", "", "vue"},
+		{"main.tf", "", "hcl"},
+		{"api.proto", "", "protobuf"},
+		{"schema.graphql", "", "graphql"},
+		{"lib/app.ex", "", "elixir"},
+		{"Main.hs", "", "haskell"},
+		{"lib/main.dart", "", "dart"},
+		{"init.lua", "", "lua"},
+		{"GNUmakefile", "", "makefile"},
+		{"CMakeLists.txt", "", "cmake"},
+		{"tsconfig.json", "", "jsonc"},
+		{"composer.lock", "", "json"},
+		{"Cargo.lock", "", "toml"},
+		{"Jenkinsfile", "", "groovy"},
+		{"notes.txt", "", ""},
+		// 複数の拡張子 (長いものから照合)
+		{"src/types.d.ts", "", "typescript"},
+		{"src/foo.test.tsx", "", "tsx"},
+		{"views/index.html.erb", "", "erb"},
+		{"views/welcome.blade.php", "", "blade"},
+		{"src/app.php", "", "php"},
+		{"config.h.cmake.in", "", "cmake"},
+		{"docker-compose.override.yml", "", "yaml"},
+		{"go.mod", "", "go-mod"},
+		// ファイル名のパターン
+		{"Dockerfile.prod", "", "dockerfile"},
+		{"deploy/Containerfile.dev", "", "dockerfile"},
+		{"Jenkinsfile.release", "", "groovy"},
+		{"Makefile.am", "", "makefile"},
+		{".env.example", "", "dotenv"},
+		{".env", "", "dotenv"},
+		{"prod.env", "", "dotenv"},
+		// 拡張子が優先される
+		{"script.rb", "#!/usr/bin/env python3\n", "ruby"},
+		// シバン
+		{"bin/deploy", "#!/usr/bin/env python3\nimport sys\n", "python"},
+		{"bin/run", "#!/bin/bash\nset -e\n", "bash"},
+		{"hooks/pre-commit", "#!/bin/sh\n", "bash"},
+		{"bin/tool", "#!/usr/bin/env -S node --harmony\n", "javascript"},
+		{"bin/env-tool", "#!/usr/bin/env PYTHONUNBUFFERED=1 python3.11\n", "python"},
+		{"bin/perl-tool", "#!/usr/local/bin/perl -w\n", "perl"},
+		// モードライン
+		{"config/rules", "# -*- mode: ruby; coding: utf-8 -*-\n", "ruby"},
+		{"config/other", "// -*- C++ -*-\n", "cpp"},
+		{"setup/init", "# vim: set ft=sh :\n", "bash"},
+		{"setup/layout", "\n\n<!-- vim: filetype=html -->\n", "html"},
+		// 先頭の内容
+		{"templates/page", "<!DOCTYPE html>\n<html>", "html"},
+		{"index", "<?php\necho 1;", "php"},
+		{"feed", "<?xml version=\"1.0\"?>", "xml"},
+		// 判定できない
+		{"notes", "just some text\n", ""},
+		{"coding", "# -*- coding: utf-8 -*-\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Detect(tt.path, []byte(tt.head)); got != tt.expected {
+				t.Errorf("Detect(%q, %q) = %q, expected %q", tt.path, tt.head, got, tt.expected)
+			}
+		})
+	}
+}
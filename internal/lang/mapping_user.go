@@ -0,0 +1,101 @@
+package lang
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping は、ユーザーが指定した拡張子・ファイル名と言語タグの対応
+// キーが "." または "*." で始まる場合は拡張子 (".tf.j2" のような複数の拡張子も可)、
+// それ以外のワイルドカードを含むキー ("Dockerfile.*" など) はファイル名のパターン、
+// それ以外はファイル名として扱います。いずれも大文字小文字を区別しません
+type Mapping map[string]string
+
+// ParseMapping は、"ext=tag" 形式の文字列をキーと言語タグに分解します
+func ParseMapping(s string) (string, string, error) {
+	key, tag, ok := strings.Cut(s, "=")
+	key, tag = strings.TrimSpace(key), strings.TrimSpace(tag)
+	if !ok || key == "" || tag == "" {
+		return "", "", fmt.Errorf("invalid language mapping %q (expected EXT=TAG or FILENAME=TAG)", s)
+	}
+	return key, tag, nil
+}
+
+// LoadMapping は、キーと言語タグの対応を記述したYAMLを読み込みます
+//
+//	".tmpl": gotemplate
+//	"*.tf.j2": hcl
+//	Jenkinsfile.ci: groovy
+//	"Dockerfile.*": dockerfile
+func LoadMapping(r io.Reader) (Mapping, error) {
+	m := Mapping{}
+	if err := yaml.NewDecoder(r).Decode(&m); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if m == nil {
+		m = Mapping{}
+	}
+	return m, nil
+}
+
+// Set は、マッピングを追加します (既存のキーは上書きされます)
+func (m Mapping) Set(key, tag string) {
+	m[key] = tag
+}
+
+// Detect は、ユーザー定義のマッピングを優先して言語を推測します
+// 一致しない場合は組み込みの判定 (lang.Detect) を使用します
+func (m Mapping) Detect(path string, head []byte) string {
+	if lang := m.lookup(filepath.Base(path)); lang != "" {
+		return lang
+	}
+	return Detect(path, head)
+}
+
+// lookup は、ファイル名の完全一致を優先し、次にファイル名のパターン、
+// 最も長い拡張子の一致の順に探します
+// 複数のパターンに一致する場合は、ワイルドカード以外の文字が多いものを優先します
+// (同じ場合はキーの辞書順で決めます)
+func (m Mapping) lookup(filename string) string {
+	name := strings.ToLower(filename)
+	var glob, globKey, ext, extKey string
+	globLen, extLen := -1, 0
+	for key, tag := range m {
+		k := strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(k, "*.") && !strings.ContainsAny(k[1:], "*?["):
+			k = k[1:]
+			fallthrough
+		case strings.HasPrefix(k, ".") && !strings.ContainsAny(k, "*?["):
+			if strings.HasSuffix(name, k) && (len(k) > extLen || len(k) == extLen && key < extKey) {
+				ext, extKey, extLen = tag, key, len(k)
+			}
+		case strings.ContainsAny(k, "*?["):
+			n := len(k) - strings.Count(k, "*") - strings.Count(k, "?")
+			if ok, _ := filepath.Match(k, name); ok && (n > globLen || n == globLen && key < globKey) {
+				glob, globKey, globLen = tag, key, n
+			}
+		default:
+			if k == name {
+				return Canonical(tag)
+			}
+		}
+	}
+	if glob != "" {
+		return Canonical(glob)
+	}
+	return Canonical(ext)
+}
+
+// Canonical は、言語名や別名 ("Go", "golang", "Emacs-Lisp" など) を言語タグに変換します
+// 不明な名前はそのまま返します
+func Canonical(name string) string {
+	if tag, ok := aliasMap[strings.ToLower(name)]; ok {
+		return tag
+	}
+	return name
+}
@@ -0,0 +1,81 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMappingDetect(t *testing.T) {
+	m, err := LoadMapping(strings.NewReader(`
+".tmpl": gotemplate
+"*.tf.j2": terraform
+Jenkinsfile.ci: groovy
+"Dockerfile.*": bash
+"Dockerfile.prod*": docker
+"*.tmpl.*": jinja
+".go": golang
+`))
+	if err != nil {
+		t.Fatalf("LoadMapping() エラー: %v", err)
+	}
+	m.Set(".dsl", "mydsl")
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"templates/index.tmpl", "gotemplate"},
+		{"infra/main.tf.j2", "hcl"}, // 別名は言語タグに変換
+		{"infra/main.j2", "jinja"},  // 組み込みの判定
+		{"ci/Jenkinsfile.ci", "groovy"},
+		{"ci/JENKINSFILE.CI", "groovy"},
+		{"rules/policy.DSL", "mydsl"},
+		{"Dockerfile.dev", "bash"},
+		{"Dockerfile.production", "dockerfile"}, // より具体的なパターンを優先
+		{"page.tmpl.html", "jinja"},             // パターンは拡張子より優先
+		{"Makefile.am", "makefile"},
+		{"main.go", "go"},
+		{"app.py", "python"},
+	}
+
+	for _, tt := range tests {
+		if got := m.Detect(tt.path, nil); got != tt.expected {
+			t.Errorf("Detect(%q) = %q, expected %q", tt.path, got, tt.expected)
+		}
+	}
+
+	// nil のマッピングは組み込みの判定のみ
+	var empty Mapping
+	if got := empty.Detect("main.go", nil); got != "go" {
+		t.Errorf("Detect() = %q, expected go", got)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	key, tag, err := ParseMapping(".tmpl=gotemplate")
+	if err != nil || key != ".tmpl" || tag != "gotemplate" {
+		t.Errorf("ParseMapping() = (%q, %q, %v)", key, tag, err)
+	}
+	for _, invalid := range []string{".tmpl", "=go", ".tmpl="} {
+		if _, _, err := ParseMapping(invalid); err == nil {
+			t.Errorf("ParseMapping(%q) はエラーを返すべきです", invalid)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]string{
+		"Go":         "go",
+		"golang":     "go",
+		"Emacs-Lisp": "elisp",
+		"C++":        "cpp",
+		"Shell":      "bash",
+		"unknown":    "unknown",
+		"":           "",
+	}
+	for input, expected := range tests {
+		if got := Canonical(input); got != expected {
+			t.Errorf("Canonical(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
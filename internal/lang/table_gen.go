@@ -0,0 +1,579 @@
+// Code generated by go run ./gen from languages.yml; DO NOT EDIT.
+
+package lang
+
+// 拡張子 (小文字) から言語タグへのマッピング
+var extMap = map[string]string{
+	".ada":         "ada",
+	".adb":         "ada",
+	".ads":         "ada",
+	".applescript": "applescript",
+	".asd":         "lisp",
+	".asm":         "asm",
+	".avsc":        "json",
+	".awk":         "awk",
+	".bash":        "bash",
+	".bat":         "batch",
+	".blade.php":   "blade",
+	".bzl":         "starlark",
+	".c":           "c",
+	".c++":         "cpp",
+	".cc":          "cpp",
+	".cfg":         "ini",
+	".cjs":         "javascript",
+	".cl":          "lisp",
+	".clj":         "clojure",
+	".cljc":        "clojure",
+	".cljs":        "clojure",
+	".cls":         "latex",
+	".cmake":       "cmake",
+	".cmake.in":    "cmake",
+	".cmd":         "batch",
+	".coffee":      "coffeescript",
+	".command":     "bash",
+	".comp":        "glsl",
+	".cpp":         "cpp",
+	".cr":          "crystal",
+	".cs":          "csharp",
+	".cshtml":      "razor",
+	".csproj":      "xml",
+	".css":         "css",
+	".csv":         "csv",
+	".csx":         "csharp",
+	".cts":         "typescript",
+	".cu":          "cuda",
+	".cuh":         "cuda",
+	".cxx":         "cpp",
+	".d":           "d",
+	".d.cts":       "typescript",
+	".d.mts":       "typescript",
+	".d.ts":        "typescript",
+	".dart":        "dart",
+	".diff":        "diff",
+	".dockerfile":  "dockerfile",
+	".dpr":         "pascal",
+	".edn":         "clojure",
+	".el":          "elisp",
+	".elm":         "elm",
+	".env":         "dotenv",
+	".erb":         "erb",
+	".erl":         "erlang",
+	".ex":          "elixir",
+	".exs":         "elixir",
+	".f":           "fortran",
+	".f03":         "fortran",
+	".f90":         "fortran",
+	".f95":         "fortran",
+	".fish":        "fish",
+	".for":         "fortran",
+	".frag":        "glsl",
+	".fs":          "fsharp",
+	".fsi":         "fsharp",
+	".fsproj":      "xml",
+	".fsx":         "fsharp",
+	".fx":          "hlsl",
+	".gemspec":     "ruby",
+	".geojson":     "json",
+	".geom":        "glsl",
+	".glsl":        "glsl",
+	".go":          "go",
+	".gql":         "graphql",
+	".gradle":      "groovy",
+	".graphql":     "graphql",
+	".graphqls":    "graphql",
+	".groovy":      "groovy",
+	".gvy":         "groovy",
+	".h":           "c",
+	".h++":         "cpp",
+	".handlebars":  "handlebars",
+	".hbs":         "handlebars",
+	".hcl":         "hcl",
+	".hh":          "cpp",
+	".hlsl":        "hlsl",
+	".hpp":         "cpp",
+	".hrl":         "erlang",
+	".hs":          "haskell",
+	".htm":         "html",
+	".html":        "html",
+	".html.erb":    "erb",
+	".hxx":         "cpp",
+	".ini":         "ini",
+	".inl":         "cpp",
+	".ipp":         "cpp",
+	".ipynb":       "json",
+	".j2":          "jinja",
+	".jade":        "pug",
+	".java":        "java",
+	".jinja":       "jinja",
+	".jinja2":      "jinja",
+	".jl":          "julia",
+	".js":          "javascript",
+	".json":        "json",
+	".json5":       "json5",
+	".jsonc":       "jsonc",
+	".jsonnet":     "jsonnet",
+	".jsx":         "jsx",
+	".ksh":         "bash",
+	".kt":          "kotlin",
+	".kts":         "kotlin",
+	".less":        "less",
+	".lhs":         "haskell",
+	".libsonnet":   "jsonnet",
+	".liquid":      "liquid",
+	".lisp":        "lisp",
+	".lock":        "toml",
+	".lsp":         "lisp",
+	".lua":         "lua",
+	".m":           "objectivec",
+	".mak":         "makefile",
+	".markdown":    "markdown",
+	".md":          "markdown",
+	".mdown":       "markdown",
+	".mdx":         "mdx",
+	".mjs":         "javascript",
+	".mk":          "makefile",
+	".mkd":         "markdown",
+	".ml":          "ocaml",
+	".mli":         "ocaml",
+	".mm":          "objectivecpp",
+	".mts":         "typescript",
+	".nasm":        "asm",
+	".nginxconf":   "nginx",
+	".nim":         "nim",
+	".nims":        "nim",
+	".nix":         "nix",
+	".p6":          "raku",
+	".pas":         "pascal",
+	".patch":       "diff",
+	".php":         "php",
+	".phtml":       "php",
+	".pl":          "perl",
+	".plist":       "xml",
+	".pm":          "perl",
+	".pp":          "pascal",
+	".pro":         "prolog",
+	".prolog":      "prolog",
+	".properties":  "properties",
+	".props":       "xml",
+	".proto":       "protobuf",
+	".ps1":         "powershell",
+	".psd1":        "powershell",
+	".psm1":        "powershell",
+	".pug":         "pug",
+	".purs":        "purescript",
+	".py":          "python",
+	".pyi":         "python",
+	".pyw":         "python",
+	".r":           "r",
+	".rake":        "ruby",
+	".raku":        "raku",
+	".rakumod":     "raku",
+	".razor":       "razor",
+	".rb":          "ruby",
+	".rkt":         "racket",
+	".rmd":         "r",
+	".rs":          "rust",
+	".rst":         "rst",
+	".ru":          "ruby",
+	".s":           "asm",
+	".sass":        "sass",
+	".sbt":         "scala",
+	".sc":          "scala",
+	".scala":       "scala",
+	".scm":         "scheme",
+	".scpt":        "applescript",
+	".scss":        "scss",
+	".sh":          "bash",
+	".sol":         "solidity",
+	".sql":         "sql",
+	".ss":          "scheme",
+	".star":        "starlark",
+	".sty":         "latex",
+	".sv":          "verilog",
+	".svelte":      "svelte",
+	".svg":         "xml",
+	".svh":         "verilog",
+	".swift":       "swift",
+	".t":           "perl",
+	".targets":     "xml",
+	".tcl":         "tcl",
+	".tex":         "latex",
+	".tf":          "hcl",
+	".tfvars":      "hcl",
+	".thrift":      "thrift",
+	".toml":        "toml",
+	".tpp":         "cpp",
+	".ts":          "typescript",
+	".tsv":         "tsv",
+	".tsx":         "tsx",
+	".twig":        "twig",
+	".v":           "verilog",
+	".vb":          "vbnet",
+	".vbproj":      "xml",
+	".vert":        "glsl",
+	".vh":          "verilog",
+	".vhd":         "vhdl",
+	".vhdl":        "vhdl",
+	".vim":         "vim",
+	".vue":         "vue",
+	".webmanifest": "json",
+	".xaml":        "xml",
+	".xhtml":       "html",
+	".xml":         "xml",
+	".xsd":         "xml",
+	".xsl":         "xml",
+	".xslt":        "xml",
+	".yaml":        "yaml",
+	".yml":         "yaml",
+	".zig":         "zig",
+	".zsh":         "zsh",
+}
+
+// ファイル名 (小文字) から言語タグへのマッピング
+var fileMap = map[string]string{
+	".babelrc":          "json",
+	".bash_aliases":     "bash",
+	".bash_logout":      "bash",
+	".bash_profile":     "bash",
+	".bashrc":           "bash",
+	".clang-format":     "yaml",
+	".clang-tidy":       "yaml",
+	".dockerignore":     "gitignore",
+	".editorconfig":     "ini",
+	".emacs":            "elisp",
+	".env":              "dotenv",
+	".eslintignore":     "gitignore",
+	".eslintrc":         "jsonc",
+	".gitattributes":    "gitattributes",
+	".gitconfig":        "gitconfig",
+	".gitignore":        "gitignore",
+	".gitmodules":       "gitconfig",
+	".gvimrc":           "vim",
+	".npmignore":        "gitignore",
+	".prettierignore":   "gitignore",
+	".profile":          "bash",
+	".rprofile":         "r",
+	".vimrc":            "vim",
+	".zlogin":           "zsh",
+	".zprofile":         "zsh",
+	".zshenv":           "zsh",
+	".zshrc":            "zsh",
+	"_vimrc":            "vim",
+	"brewfile":          "ruby",
+	"build":             "starlark",
+	"build.bazel":       "starlark",
+	"cargo.lock":        "toml",
+	"cmakelists.txt":    "cmake",
+	"composer.lock":     "json",
+	"containerfile":     "dockerfile",
+	"devcontainer.json": "jsonc",
+	"dockerfile":        "dockerfile",
+	"gemfile":           "ruby",
+	"gemfile.lock":      "text",
+	"gnumakefile":       "makefile",
+	"go.mod":            "go-mod",
+	"go.sum":            "text",
+	"go.work":           "go-mod",
+	"go.work.sum":       "text",
+	"guardfile":         "ruby",
+	"jenkinsfile":       "groovy",
+	"jsconfig.json":     "jsonc",
+	"makefile":          "makefile",
+	"meson.build":       "meson",
+	"meson_options.txt": "meson",
+	"module.bazel":      "starlark",
+	"nginx.conf":        "nginx",
+	"pipfile":           "toml",
+	"pipfile.lock":      "json",
+	"podfile":           "ruby",
+	"poetry.lock":       "toml",
+	"pom.xml":           "xml",
+	"rakefile":          "ruby",
+	"rebar.config":      "erlang",
+	"sconscript":        "python",
+	"sconstruct":        "python",
+	"tiltfile":          "starlark",
+	"tsconfig.json":     "jsonc",
+	"uv.lock":           "toml",
+	"vagrantfile":       "ruby",
+	"workspace":         "starlark",
+	"workspace.bazel":   "starlark",
+	"yarn.lock":         "yaml",
+}
+
+// ファイル名のパターン (小文字) と言語タグ (具体的なものから順に照合)
+var patternRules = []patternRule{
+	{"containerfile.*", "dockerfile"},
+	{"jenkinsfile.*", "groovy"},
+	{"dockerfile.*", "dockerfile"},
+	{"makefile.*", "makefile"},
+	{".env.*", "dotenv"},
+}
+
+// シバンのインタプリタ名から言語タグへのマッピング
+var interpreterMap = map[string]string{
+	"Rscript":    "r",
+	"ash":        "bash",
+	"awk":        "awk",
+	"bash":       "bash",
+	"bun":        "typescript",
+	"clisp":      "lisp",
+	"crystal":    "crystal",
+	"csi":        "scheme",
+	"dash":       "bash",
+	"deno":       "typescript",
+	"elixir":     "elixir",
+	"escript":    "erlang",
+	"fish":       "fish",
+	"gawk":       "awk",
+	"groovy":     "groovy",
+	"guile":      "scheme",
+	"jruby":      "ruby",
+	"julia":      "julia",
+	"ksh":        "bash",
+	"lua":        "lua",
+	"luajit":     "lua",
+	"make":       "makefile",
+	"mawk":       "awk",
+	"nawk":       "awk",
+	"node":       "javascript",
+	"nodejs":     "javascript",
+	"ocaml":      "ocaml",
+	"osascript":  "applescript",
+	"perl":       "perl",
+	"perl6":      "raku",
+	"php":        "php",
+	"pwsh":       "powershell",
+	"pypy":       "python",
+	"python":     "python",
+	"racket":     "racket",
+	"raku":       "raku",
+	"ruby":       "ruby",
+	"runhaskell": "haskell",
+	"sbcl":       "lisp",
+	"scala":      "scala",
+	"sh":         "bash",
+	"swipl":      "prolog",
+	"tclsh":      "tcl",
+	"ts-node":    "typescript",
+	"wish":       "tcl",
+	"zsh":        "zsh",
+}
+
+// 言語名・別名 (小文字) から言語タグへのマッピング
+var aliasMap = map[string]string{
+	"ada":                "ada",
+	"applescript":        "applescript",
+	"asm":                "asm",
+	"assembly":           "asm",
+	"awk":                "awk",
+	"bash":               "bash",
+	"bat":                "batch",
+	"batch":              "batch",
+	"batchfile":          "batch",
+	"bazel":              "starlark",
+	"blade":              "blade",
+	"bzl":                "starlark",
+	"c":                  "c",
+	"c#":                 "csharp",
+	"c++":                "cpp",
+	"cfg":                "ini",
+	"cl":                 "lisp",
+	"clj":                "clojure",
+	"clojure":            "clojure",
+	"cmake":              "cmake",
+	"cmd":                "batch",
+	"coffee":             "coffeescript",
+	"coffeescript":       "coffeescript",
+	"common lisp":        "lisp",
+	"common-lisp":        "lisp",
+	"containerfile":      "dockerfile",
+	"cpp":                "cpp",
+	"crystal":            "crystal",
+	"cs":                 "csharp",
+	"csharp":             "csharp",
+	"cshtml":             "razor",
+	"css":                "css",
+	"csv":                "csv",
+	"cuda":               "cuda",
+	"d":                  "d",
+	"dart":               "dart",
+	"diff":               "diff",
+	"django":             "jinja",
+	"docker":             "dockerfile",
+	"dockerfile":         "dockerfile",
+	"dosini":             "ini",
+	"dotenv":             "dotenv",
+	"editorconfig":       "ini",
+	"elisp":              "elisp",
+	"elixir":             "elixir",
+	"elm":                "elm",
+	"emacs lisp":         "elisp",
+	"emacs-lisp":         "elisp",
+	"env":                "dotenv",
+	"erb":                "erb",
+	"erl":                "erlang",
+	"erlang":             "erlang",
+	"ex":                 "elixir",
+	"f#":                 "fsharp",
+	"fish":               "fish",
+	"fortran":            "fortran",
+	"fsharp":             "fsharp",
+	"gas":                "asm",
+	"geojson":            "json",
+	"git attributes":     "gitattributes",
+	"git config":         "gitconfig",
+	"git-attributes":     "gitattributes",
+	"git-config":         "gitconfig",
+	"gitattributes":      "gitattributes",
+	"gitconfig":          "gitconfig",
+	"gitignore":          "gitignore",
+	"glsl":               "glsl",
+	"go":                 "go",
+	"go module":          "go-mod",
+	"go-mod":             "go-mod",
+	"go-module":          "go-mod",
+	"golang":             "go",
+	"gql":                "graphql",
+	"graphql":            "graphql",
+	"groovy":             "groovy",
+	"handlebars":         "handlebars",
+	"haskell":            "haskell",
+	"hbs":                "handlebars",
+	"hcl":                "hcl",
+	"hlsl":               "hlsl",
+	"hs":                 "haskell",
+	"html":               "html",
+	"html+erb":           "erb",
+	"ignore list":        "gitignore",
+	"ignore-list":        "gitignore",
+	"ini":                "ini",
+	"jade":               "pug",
+	"java":               "java",
+	"java properties":    "properties",
+	"java-properties":    "properties",
+	"javascript":         "javascript",
+	"jinja":              "jinja",
+	"jinja2":             "jinja",
+	"jl":                 "julia",
+	"js":                 "javascript",
+	"json":               "json",
+	"json with comments": "jsonc",
+	"json-with-comments": "jsonc",
+	"json5":              "json5",
+	"jsonc":              "jsonc",
+	"jsonl":              "json",
+	"jsonnet":            "jsonnet",
+	"jsx":                "jsx",
+	"julia":              "julia",
+	"kotlin":             "kotlin",
+	"ksh":                "bash",
+	"kt":                 "kotlin",
+	"latex":              "latex",
+	"less":               "less",
+	"liquid":             "liquid",
+	"lisp":               "lisp",
+	"lua":                "lua",
+	"make":               "makefile",
+	"makefile":           "makefile",
+	"markdown":           "markdown",
+	"md":                 "markdown",
+	"mdx":                "mdx",
+	"meson":              "meson",
+	"mf":                 "makefile",
+	"nasm":               "asm",
+	"nginx":              "nginx",
+	"nim":                "nim",
+	"nix":                "nix",
+	"node":               "javascript",
+	"obj-c":              "objectivec",
+	"objc":               "objectivec",
+	"objc++":             "objectivecpp",
+	"objective-c":        "objectivec",
+	"objective-c++":      "objectivecpp",
+	"objectivec":         "objectivec",
+	"objectivecpp":       "objectivecpp",
+	"ocaml":              "ocaml",
+	"pascal":             "pascal",
+	"patch":              "diff",
+	"perl":               "perl",
+	"perl6":              "raku",
+	"php":                "php",
+	"pl":                 "perl",
+	"plaintext":          "text",
+	"posh":               "powershell",
+	"powershell":         "powershell",
+	"prolog":             "prolog",
+	"properties":         "properties",
+	"proto":              "protobuf",
+	"protobuf":           "protobuf",
+	"protocol buffer":    "protobuf",
+	"protocol-buffer":    "protobuf",
+	"ps1":                "powershell",
+	"pug":                "pug",
+	"purescript":         "purescript",
+	"purs":               "purescript",
+	"pwsh":               "powershell",
+	"py":                 "python",
+	"python":             "python",
+	"python3":            "python",
+	"r":                  "r",
+	"racket":             "racket",
+	"raku":               "raku",
+	"razor":              "razor",
+	"rb":                 "ruby",
+	"restructuredtext":   "rst",
+	"rs":                 "rust",
+	"rscript":            "r",
+	"rss":                "xml",
+	"rst":                "rst",
+	"ruby":               "ruby",
+	"rust":               "rust",
+	"sass":               "sass",
+	"scala":              "scala",
+	"scheme":             "scheme",
+	"scss":               "scss",
+	"sh":                 "bash",
+	"shell":              "bash",
+	"shell-script":       "bash",
+	"solidity":           "solidity",
+	"splus":              "r",
+	"sql":                "sql",
+	"starlark":           "starlark",
+	"svelte":             "svelte",
+	"svg":                "xml",
+	"swift":              "swift",
+	"tcl":                "tcl",
+	"terraform":          "hcl",
+	"tex":                "latex",
+	"text":               "text",
+	"tf":                 "hcl",
+	"thrift":             "thrift",
+	"toml":               "toml",
+	"ts":                 "typescript",
+	"tsv":                "tsv",
+	"tsx":                "tsx",
+	"twig":               "twig",
+	"txt":                "text",
+	"typescript":         "typescript",
+	"vb":                 "vbnet",
+	"vbnet":              "vbnet",
+	"verilog":            "verilog",
+	"vhdl":               "vhdl",
+	"vim":                "vim",
+	"vim script":         "vim",
+	"vim-script":         "vim",
+	"viml":               "vim",
+	"vimscript":          "vim",
+	"visual basic .net":  "vbnet",
+	"visual-basic-.net":  "vbnet",
+	"vue":                "vue",
+	"xhtml":              "html",
+	"xml":                "xml",
+	"xsd":                "xml",
+	"xsl":                "xml",
+	"yaml":               "yaml",
+	"yml":                "yaml",
+	"zig":                "zig",
+	"zsh":                "zsh",
+}
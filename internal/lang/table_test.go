@@ -0,0 +1,33 @@
+package lang
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// table_gen.go が languages.yml から再生成した内容と一致することを検証
+func TestTableUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("go run を使用するため -short では省略")
+	}
+
+	out := filepath.Join(t.TempDir(), "table_gen.go")
+	cmd := exec.Command("go", "run", "./gen", "-out", out)
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go run ./gen に失敗: %v\n%s", err, msg)
+	}
+
+	want, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("table_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Error("table_gen.go が languages.yml と一致しません。go generate ./internal/lang を実行してください")
+	}
+}
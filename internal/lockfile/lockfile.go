@@ -0,0 +1,122 @@
+package lockfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Package は、ロックファイルに記録された依存パッケージ
+type Package struct {
+	Name    string
+	Version string
+	Direct  bool // 直接の依存関係かどうか (判別できない形式では常に false)
+}
+
+// Summary は、ロックファイルの要約
+type Summary struct {
+	Format    string    // ロックファイルの形式 (ファイル名)
+	Packages  []Package // 名前順に並んだパッケージ
+	HasDirect bool      // 直接の依存関係を判別できたかどうか
+}
+
+// parser は、ロックファイルの内容をパッケージ一覧に変換します
+type parser func(data []byte) ([]Package, error)
+
+// ファイル名とパーサーの対応
+var parsers = map[string]parser{
+	"go.sum":              parseGoSum,
+	"package-lock.json":   parsePackageLock,
+	"npm-shrinkwrap.json": parsePackageLock,
+	"yarn.lock":           parseYarnLock,
+	"pnpm-lock.yaml":      parsePnpmLock,
+	"poetry.lock":         parseTOMLPackages,
+	"Cargo.lock":          parseTOMLPackages,
+	"uv.lock":             parseTOMLPackages,
+	"Pipfile.lock":        parsePipfileLock,
+	"composer.lock":       parseComposerLock,
+	"Gemfile.lock":        parseGemfileLock,
+}
+
+// IsLockfile は、ファイル名が対応しているロックファイルかどうかを返します
+func IsLockfile(name string) bool {
+	_, ok := parsers[name]
+	return ok
+}
+
+// Summarize は、ロックファイルを解析して要約を返します
+func Summarize(name string, data []byte) (*Summary, error) {
+	parse, ok := parsers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported lockfile %q", name)
+	}
+	pkgs, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if pkgs[i].Name != pkgs[j].Name {
+			return pkgs[i].Name < pkgs[j].Name
+		}
+		return pkgs[i].Version < pkgs[j].Version
+	})
+
+	s := &Summary{Format: name, Packages: pkgs}
+	for _, p := range pkgs {
+		if p.Direct {
+			s.HasDirect = true
+			break
+		}
+	}
+	return s, nil
+}
+
+// String は、要約をコードブロック内に出力するテキストに変換します
+// 直接の依存関係を判別できた場合はそれだけを、できない場合はすべてのパッケージを列挙します
+func (s *Summary) String() string {
+	var buf bytes.Buffer
+	listed := s.Packages
+	if s.HasDirect {
+		listed = nil
+		for _, p := range s.Packages {
+			if p.Direct {
+				listed = append(listed, p)
+			}
+		}
+		fmt.Fprintf(&buf, "# lockfile summary (%s): %d direct dependencies, %d packages in total\n", s.Format, len(listed), len(s.Packages))
+	} else {
+		fmt.Fprintf(&buf, "# lockfile summary (%s): %d packages\n", s.Format, len(s.Packages))
+	}
+
+	for _, p := range listed {
+		if p.Version == "" {
+			fmt.Fprintln(&buf, p.Name)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s %s\n", p.Name, p.Version)
+	}
+	return buf.String()
+}
+
+// lines は、データを行ごとに分割して返します
+func lines(data []byte) []string {
+	var out []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		out = append(out, sc.Text())
+	}
+	return out
+}
+
+// unquote は、値を囲む引用符を取り除きます
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
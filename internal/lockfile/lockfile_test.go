@@ -0,0 +1,247 @@
+package lockfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		data      string
+		expected  []Package
+		hasDirect bool
+	}{
+		{
+			name: "go.sum",
+			file: "go.sum",
+			data: `github.com/spf13/cobra v1.8.0 h1:abc=
+github.com/spf13/cobra v1.8.0/go.mod h1:def=
+github.com/spf13/pflag v1.0.5 h1:ghi=
+github.com/spf13/pflag v1.0.5/go.mod h1:jkl=
+golang.org/x/text v0.3.0/go.mod h1:mno=
+`,
+			expected: []Package{
+				{Name: "github.com/spf13/cobra", Version: "v1.8.0"},
+				{Name: "github.com/spf13/pflag", Version: "v1.0.5"},
+			},
+		},
+		{
+			name: "package-lock.json v3",
+			file: "package-lock.json",
+			data: `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"dependencies": {"react": "^18.0.0"}, "devDependencies": {"vitest": "^1.0.0"}},
+    "node_modules/react": {"version": "18.2.0"},
+    "node_modules/loose-envify": {"version": "1.4.0"},
+    "node_modules/vitest": {"version": "1.1.0"},
+    "node_modules/vitest/node_modules/react": {"version": "17.0.0"}
+  }
+}`,
+			expected: []Package{
+				{Name: "loose-envify", Version: "1.4.0"},
+				{Name: "react", Version: "17.0.0"},
+				{Name: "react", Version: "18.2.0", Direct: true},
+				{Name: "vitest", Version: "1.1.0", Direct: true},
+			},
+			hasDirect: true,
+		},
+		{
+			name: "yarn.lock",
+			file: "yarn.lock",
+			data: `# yarn lockfile v1
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.23.0"
+  resolved "https://registry.yarnpkg.com/..."
+
+lodash@^4.17.0:
+  version "4.17.21"
+`,
+			expected: []Package{
+				{Name: "@babel/core", Version: "7.23.0"},
+				{Name: "lodash", Version: "4.17.21"},
+			},
+		},
+		{
+			name: "package-lock.json workspaces",
+			file: "package-lock.json",
+			data: `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "monorepo", "workspaces": ["packages/*"], "devDependencies": {"typescript": "^5.0.0"}},
+    "node_modules/@acme/a": {"resolved": "packages/a", "link": true},
+    "node_modules/typescript": {"version": "5.3.0"},
+    "node_modules/react": {"version": "18.2.0"},
+    "packages/a": {"name": "@acme/a", "version": "0.1.0", "dependencies": {"react": "^18.0.0"}},
+    "packages/a/node_modules/lodash": {"version": "4.17.21"}
+  }
+}`,
+			expected: []Package{
+				{Name: "@acme/a", Version: "0.1.0"},
+				{Name: "lodash", Version: "4.17.21"},
+				{Name: "react", Version: "18.2.0", Direct: true},
+				{Name: "typescript", Version: "5.3.0", Direct: true},
+			},
+			hasDirect: true,
+		},
+		{
+			name: "yarn.lock berry",
+			file: "yarn.lock",
+			data: `# This file is generated by running "yarn install" inside your project.
+
+__metadata:
+  version: 8
+  cacheKey: 10c0
+
+"@babel/core@npm:^7.0.0":
+  version: 7.23.0
+  resolution: "@babel/core@npm:7.23.0"
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+`,
+			expected: []Package{
+				{Name: "@babel/core", Version: "7.23.0"},
+				{Name: "app", Version: "0.0.0-use.local"},
+			},
+		},
+		{
+			name: "pnpm-lock.yaml",
+			file: "pnpm-lock.yaml",
+			data: `lockfileVersion: '9.0'
+
+importers:
+  .:
+    dependencies:
+      lodash:
+        specifier: ^4.17.0
+        version: 4.17.21
+
+packages:
+  '@babel/core@7.23.0':
+    resolution: {integrity: sha512-abc}
+  lodash@4.17.21:
+    resolution: {integrity: sha512-def}
+`,
+			expected: []Package{
+				{Name: "@babel/core", Version: "7.23.0"},
+				{Name: "lodash", Version: "4.17.21"},
+			},
+		},
+		{
+			name: "Cargo.lock",
+			file: "Cargo.lock",
+			data: `# This file is automatically @generated by Cargo.
+version = 3
+
+[[package]]
+name = "serde"
+version = "1.0.190"
+dependencies = [
+ "serde_derive",
+]
+
+[[package]]
+name = "anyhow"
+version = "1.0.75"
+`,
+			expected: []Package{
+				{Name: "anyhow", Version: "1.0.75"},
+				{Name: "serde", Version: "1.0.190"},
+			},
+		},
+		{
+			name: "Pipfile.lock",
+			file: "Pipfile.lock",
+			data: `{"_meta": {}, "default": {"requests": {"version": "==2.31.0"}}, "develop": {"pytest": {"version": "==7.4.0"}}}`,
+			expected: []Package{
+				{Name: "pytest", Version: "7.4.0"},
+				{Name: "requests", Version: "2.31.0"},
+			},
+		},
+		{
+			name: "composer.lock",
+			file: "composer.lock",
+			data: `{"packages": [{"name": "monolog/monolog", "version": "3.5.0"}], "packages-dev": [{"name": "phpunit/phpunit", "version": "10.4.0"}]}`,
+			expected: []Package{
+				{Name: "monolog/monolog", Version: "3.5.0"},
+				{Name: "phpunit/phpunit", Version: "10.4.0"},
+			},
+		},
+		{
+			name: "Gemfile.lock",
+			file: "Gemfile.lock",
+			data: `GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.8)
+    rails (7.1.0)
+      rack (>= 2.2.4)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  rails (~> 7.1)
+`,
+			expected: []Package{
+				{Name: "rack", Version: "3.0.8"},
+				{Name: "rails", Version: "7.1.0", Direct: true},
+			},
+			hasDirect: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Summarize(tt.file, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Summarize() エラー: %v", err)
+			}
+			if s.HasDirect != tt.hasDirect {
+				t.Errorf("HasDirect = %v, expected %v", s.HasDirect, tt.hasDirect)
+			}
+			if len(s.Packages) != len(tt.expected) {
+				t.Fatalf("Packages = %+v, expected %+v", s.Packages, tt.expected)
+			}
+			for i, p := range s.Packages {
+				if p != tt.expected[i] {
+					t.Errorf("Packages[%d] = %+v, expected %+v", i, p, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeUnsupported(t *testing.T) {
+	if _, err := Summarize("requirements.txt", nil); err == nil {
+		t.Error("未対応のファイルではエラーを返すべきです")
+	}
+	if _, err := Summarize("package-lock.json", []byte("{")); err == nil {
+		t.Error("不正なJSONではエラーを返すべきです")
+	}
+}
+
+func TestSummaryString(t *testing.T) {
+	s := &Summary{
+		Format: "package-lock.json",
+		Packages: []Package{
+			{Name: "loose-envify", Version: "1.4.0"},
+			{Name: "react", Version: "18.2.0", Direct: true},
+		},
+		HasDirect: true,
+	}
+	expected := "# lockfile summary (package-lock.json): 1 direct dependencies, 2 packages in total\nreact 18.2.0\n"
+	if got := s.String(); got != expected {
+		t.Errorf("String() = %q, expected %q", got, expected)
+	}
+
+	s.HasDirect = false
+	if got := s.String(); !strings.HasPrefix(got, "# lockfile summary (package-lock.json): 2 packages\n") {
+		t.Errorf("String() = %q", got)
+	}
+}
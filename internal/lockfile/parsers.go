@@ -0,0 +1,250 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// parseGoSum は、go.sum からモジュールとバージョンの一覧を取得します
+// go.mod のハッシュのみの行 (ビルドに使われないバージョン) は除外します
+func parseGoSum(data []byte) ([]Package, error) {
+	seen := map[string]bool{}
+	var pkgs []Package
+	for _, line := range lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		key := fields[0] + "@" + fields[1]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pkgs = append(pkgs, Package{Name: fields[0], Version: fields[1]})
+	}
+	return pkgs, nil
+}
+
+// parsePackageLock は、package-lock.json (lockfileVersion 1〜3) を解析します
+// workspaces を使用している場合は、各ワークスペースの依存関係も直接の依存関係とします
+func parsePackageLock(data []byte) ([]Package, error) {
+	type entry struct {
+		Name            string            `json:"name"`
+		Version         string            `json:"version"`
+		Link            bool              `json:"link"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	var lock struct {
+		Packages     map[string]entry `json:"packages"`
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	if len(lock.Packages) > 0 {
+		// lockfileVersion 2/3: "" がルートパッケージ、
+		// "node_modules/" を含まないキー ("packages/a" など) はワークスペース
+		direct := map[string]bool{}
+		for path, p := range lock.Packages {
+			if path == "" || !strings.Contains(path, "node_modules/") {
+				for name := range p.Dependencies {
+					direct[name] = true
+				}
+				for name := range p.DevDependencies {
+					direct[name] = true
+				}
+			}
+		}
+		for path, p := range lock.Packages {
+			// ワークスペースへのリンクは、ワークスペース自体のエントリで数える
+			if path == "" || p.Link {
+				continue
+			}
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 {
+				if p.Name != "" {
+					pkgs = append(pkgs, Package{Name: p.Name, Version: p.Version})
+				}
+				continue
+			}
+			name := path[i+len("node_modules/"):]
+			pkgs = append(pkgs, Package{Name: name, Version: p.Version, Direct: direct[name] && path == "node_modules/"+name})
+		}
+		return pkgs, nil
+	}
+
+	// lockfileVersion 1: 直接の依存関係は判別できない
+	for name, d := range lock.Dependencies {
+		pkgs = append(pkgs, Package{Name: name, Version: d.Version})
+	}
+	return pkgs, nil
+}
+
+// parseYarnLock は、yarn.lock (v1 および berry) を解析します
+func parseYarnLock(data []byte) ([]Package, error) {
+	var pkgs []Package
+	name := ""
+	for _, line := range lines(data) {
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case !strings.HasPrefix(line, " ") && strings.HasSuffix(line, ":"):
+			// "name@^1.0.0", name@^1.1.0: のような見出し行
+			spec := unquote(strings.TrimSpace(strings.Split(strings.TrimSuffix(line, ":"), ",")[0]))
+			if i := strings.LastIndex(spec, "@"); i > 0 {
+				spec = spec[:i]
+			}
+			name = spec
+			if name == "__metadata" {
+				// berry のファイル情報 (version はロックファイルの形式)
+				name = ""
+			}
+		case name != "" && strings.HasPrefix(strings.TrimSpace(line), "version"):
+			v := strings.TrimPrefix(strings.TrimSpace(line), "version")
+			pkgs = append(pkgs, Package{Name: name, Version: unquote(strings.TrimPrefix(strings.TrimSpace(v), ":"))})
+			name = ""
+		}
+	}
+	return pkgs, nil
+}
+
+// parsePnpmLock は、pnpm-lock.yaml の packages セクションを解析します
+// キーは "/name/1.0.0" (v5)、"/name@1.0.0" (v6)、"name@1.0.0" (v9) の形式です
+func parsePnpmLock(data []byte) ([]Package, error) {
+	var pkgs []Package
+	inPackages := false
+	for _, line := range lines(data) {
+		if !strings.HasPrefix(line, " ") {
+			inPackages = strings.TrimSpace(line) == "packages:"
+			continue
+		}
+		if !inPackages || strings.HasPrefix(line, "   ") || !strings.HasSuffix(line, ":") {
+			continue
+		}
+
+		key := strings.TrimPrefix(unquote(strings.TrimSuffix(strings.TrimSpace(line), ":")), "/")
+		if i := strings.Index(key, "("); i > 0 {
+			key = key[:i] // ピア依存の注記を除去
+		}
+		name, version := key, ""
+		if i := strings.LastIndex(key, "@"); i > 0 {
+			name, version = key[:i], key[i+1:]
+		} else if i := strings.LastIndex(key, "/"); i > 0 {
+			name, version = key[:i], key[i+1:]
+		}
+		pkgs = append(pkgs, Package{Name: name, Version: version})
+	}
+	return pkgs, nil
+}
+
+// parseTOMLPackages は、poetry.lock / Cargo.lock / uv.lock の [[package]] テーブルを解析します
+func parseTOMLPackages(data []byte) ([]Package, error) {
+	var pkgs []Package
+	var cur *Package
+	for _, line := range lines(data) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			cur = nil
+			if line == "[[package]]" {
+				pkgs = append(pkgs, Package{})
+				cur = &pkgs[len(pkgs)-1]
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "name":
+			cur.Name = unquote(value)
+		case "version":
+			cur.Version = unquote(value)
+		}
+	}
+	return pkgs, nil
+}
+
+// parsePipfileLock は、Pipfile.lock の default / develop セクションを解析します
+func parsePipfileLock(data []byte) ([]Package, error) {
+	var lock map[string]json.RawMessage
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for _, section := range []string{"default", "develop"} {
+		var deps map[string]struct {
+			Version string `json:"version"`
+		}
+		if raw, ok := lock[section]; ok {
+			if err := json.Unmarshal(raw, &deps); err != nil {
+				return nil, err
+			}
+		}
+		for name, d := range deps {
+			pkgs = append(pkgs, Package{Name: name, Version: strings.TrimPrefix(d.Version, "==")})
+		}
+	}
+	return pkgs, nil
+}
+
+// parseComposerLock は、composer.lock の packages / packages-dev を解析します
+func parseComposerLock(data []byte) ([]Package, error) {
+	type entry struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var lock struct {
+		Packages    []entry `json:"packages"`
+		PackagesDev []entry `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for _, e := range append(lock.Packages, lock.PackagesDev...) {
+		pkgs = append(pkgs, Package{Name: e.Name, Version: e.Version})
+	}
+	return pkgs, nil
+}
+
+// parseGemfileLock は、Gemfile.lock の specs と DEPENDENCIES セクションを解析します
+func parseGemfileLock(data []byte) ([]Package, error) {
+	var pkgs []Package
+	direct := map[string]bool{}
+	section := ""
+	for _, line := range lines(data) {
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = line
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		fields := strings.Fields(line)
+		switch {
+		case section == "DEPENDENCIES" && indent == 2:
+			direct[strings.TrimSuffix(fields[0], "!")] = true
+		case section != "DEPENDENCIES" && indent == 4 && len(fields) == 2:
+			// "    name (1.2.3)" の形式
+			pkgs = append(pkgs, Package{Name: fields[0], Version: strings.Trim(fields[1], "()")})
+		}
+	}
+
+	for i := range pkgs {
+		pkgs[i].Direct = direct[pkgs[i].Name]
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,233 @@
+// Package logging は、診断メッセージを log/slog で出力するための補助機能を提供します
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// LevelTrace は、ファイルごとの読み込み結果など、最も詳細なメッセージのレベル (-vv)
+const LevelTrace = slog.LevelDebug - 4
+
+// 出力形式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Level は、--quiet と -v の指定回数から出力するレベルを返します
+//
+//	--quiet: エラーのみ
+//	なし:    警告と概要 (合計、機密ファイルの除外など)
+//	-v:      除外したファイルとその理由
+//	-vv:     読み込んだファイルごとの統計
+func Level(quiet bool, verbose int) slog.Level {
+	switch {
+	case quiet:
+		return slog.LevelError
+	case verbose >= 2:
+		return LevelTrace
+	case verbose == 1:
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// New は、w に format 形式で level 以上のメッセージを出力するロガーを作成します
+// text 形式では、端末で読みやすいよう時刻を省略します
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevel}
+	switch format {
+	case FormatText, "":
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return replaceLevel(groups, a)
+		}
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (expected text or json)", format)
+}
+
+// replaceLevel は、LevelTrace を "TRACE" と表示します
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if l, ok := a.Value.Any().(slog.Level); ok && l <= LevelTrace {
+			a.Value = slog.StringValue("TRACE")
+		}
+	}
+	return a
+}
+
+// OrDiscard は、l が nil の場合に何も出力しないロガーを返します
+func OrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.New(discardHandler{})
+	}
+	return l
+}
+
+// discardHandler は、すべてのメッセージを破棄します
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+// Recorder は、メッセージを保持しておき、後から Flush で元のハンドラに出力します
+// 並列に処理した結果のメッセージを、出力の順番がきてからまとめて出力するために使用します
+type Recorder struct {
+	target  slog.Handler
+	attrs   []slog.Attr
+	records *[]slog.Record
+}
+
+// NewRecorder は、target に出力するメッセージを保持する Recorder を作成します
+func NewRecorder(target slog.Handler) *Recorder {
+	return &Recorder{target: target, records: &[]slog.Record{}}
+}
+
+func (r *Recorder) Enabled(ctx context.Context, level slog.Level) bool {
+	return r.target.Enabled(ctx, level)
+}
+
+func (r *Recorder) Handle(_ context.Context, rec slog.Record) error {
+	rec = rec.Clone()
+	rec.AddAttrs(r.attrs...)
+	*r.records = append(*r.records, rec)
+	return nil
+}
+
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *r
+	c.attrs = append(append([]slog.Attr{}, r.attrs...), attrs...)
+	return &c
+}
+
+// WithGroup は、グループを使用しないため属性をそのまま引き継ぎます
+func (r *Recorder) WithGroup(string) slog.Handler {
+	return r
+}
+
+// Records は、保持しているメッセージを返します
+func (r *Recorder) Records() []slog.Record {
+	return *r.records
+}
+
+// Flush は、保持していたメッセージを元のハンドラに出力します
+func (r *Recorder) Flush(ctx context.Context) {
+	for _, rec := range *r.records {
+		r.target.Handle(ctx, rec)
+	}
+	*r.records = nil
+}
+
+// Counter は、警告とエラーの件数を数えながら元のハンドラに出力します
+// 元のハンドラの出力レベルにかかわらず、警告以上のメッセージはすべて数えます
+type Counter struct {
+	target slog.Handler
+	strict bool
+	attrs  []slog.Attr
+	state  *counterState
+}
+
+type counterState struct {
+	mu       sync.Mutex
+	errors   int
+	warnings int
+	failed   []string // エラーとなったパス (path 属性の値)
+}
+
+// NewCounter は、target に出力する Counter を作成します
+// strict が true の場合、警告をエラーとして扱います
+func NewCounter(target slog.Handler, strict bool) *Counter {
+	return &Counter{target: target, strict: strict, state: &counterState{}}
+}
+
+func (c *Counter) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn || c.target.Enabled(ctx, level)
+}
+
+func (c *Counter) Handle(ctx context.Context, rec slog.Record) error {
+	if rec.Level >= slog.LevelWarn {
+		if c.strict && rec.Level < slog.LevelError {
+			rec.Level = slog.LevelError
+		}
+		c.count(rec)
+	}
+	if !c.target.Enabled(ctx, rec.Level) {
+		return nil
+	}
+	return c.target.Handle(ctx, rec)
+}
+
+// count は、警告またはエラーを1件数えます
+func (c *Counter) count(rec slog.Record) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	if rec.Level < slog.LevelError {
+		c.state.warnings++
+		return
+	}
+	c.state.errors++
+
+	path := ""
+	find := func(a slog.Attr) bool {
+		if a.Key == "path" {
+			path = a.Value.String()
+			return false
+		}
+		return true
+	}
+	rec.Attrs(find)
+	for _, a := range c.attrs {
+		if path != "" {
+			break
+		}
+		find(a)
+	}
+	if path != "" {
+		c.state.failed = append(c.state.failed, path)
+	}
+}
+
+func (c *Counter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	d := *c
+	d.target = c.target.WithAttrs(attrs)
+	d.attrs = append(append([]slog.Attr{}, c.attrs...), attrs...)
+	return &d
+}
+
+func (c *Counter) WithGroup(name string) slog.Handler {
+	d := *c
+	d.target = c.target.WithGroup(name)
+	return &d
+}
+
+// Errors は、これまでに出力したエラーの件数を返します (strict の場合は警告を含みます)
+func (c *Counter) Errors() int {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return c.state.errors
+}
+
+// Warnings は、これまでに出力した警告の件数を返します
+func (c *Counter) Warnings() int {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return c.state.warnings
+}
+
+// Failed は、エラーとなったパスを出力した順に返します
+func (c *Counter) Failed() []string {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return append([]string{}, c.state.failed...)
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		quiet    bool
+		verbose  int
+		expected slog.Level
+	}{
+		{false, 0, slog.LevelInfo},
+		{false, 1, slog.LevelDebug},
+		{false, 2, LevelTrace},
+		{false, 5, LevelTrace},
+		{true, 0, slog.LevelError},
+	}
+	for _, tt := range tests {
+		if got := Level(tt.quiet, tt.verbose); got != tt.expected {
+			t.Errorf("Level(%v, %d) = %v, expected %v", tt.quiet, tt.verbose, got, tt.expected)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		format   string
+		level    slog.Level
+		expected string
+	}{
+		{"text", slog.LevelInfo, "level=WARN msg=\"skipped binary file\" path=a.png\n"},
+		{"", LevelTrace, "level=TRACE msg=loaded path=a.go\nlevel=WARN msg=\"skipped binary file\" path=a.png\n"},
+		{"text", slog.LevelError, ""},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		l, err := New(&b, tt.format, tt.level)
+		if err != nil {
+			t.Fatalf("New(%q) エラー: %v", tt.format, err)
+		}
+		l.Log(context.Background(), LevelTrace, "loaded", "path", "a.go")
+		l.Warn("skipped binary file", "path", "a.png")
+		if b.String() != tt.expected {
+			t.Errorf("New(%q, %v) の出力 = %q, expected %q", tt.format, tt.level, b.String(), tt.expected)
+		}
+	}
+
+	// JSON 形式は1行に1つのオブジェクトを出力する
+	var b strings.Builder
+	l, err := New(&b, FormatJSON, LevelTrace)
+	if err != nil {
+		t.Fatalf("New(json) エラー: %v", err)
+	}
+	l.Log(context.Background(), LevelTrace, "loaded", "path", "a.go")
+	if !strings.Contains(b.String(), `"level":"TRACE","msg":"loaded","path":"a.go"}`) {
+		t.Errorf("New(json) の出力 = %q", b.String())
+	}
+
+	if _, err := New(&b, "xml", slog.LevelInfo); err == nil {
+		t.Error("不明な形式はエラーを返すべきです")
+	}
+}
+
+// Recorder が Flush するまでメッセージを保持し、元のハンドラに順に出力することを検証
+func TestRecorder(t *testing.T) {
+	var b strings.Builder
+	target, _ := New(&b, FormatText, slog.LevelInfo)
+
+	rec := NewRecorder(target.Handler())
+	l := slog.New(rec)
+	l.Debug("hidden")
+	l.Info("first", "n", 1)
+	l.With("path", "a.go").Warn("second")
+	if b.Len() != 0 {
+		t.Fatalf("Flush 前に出力されています: %q", b.String())
+	}
+	if n := len(rec.Records()); n != 2 {
+		t.Errorf("len(Records()) = %d, expected 2", n)
+	}
+
+	rec.Flush(context.Background())
+	expected := "level=INFO msg=first n=1\nlevel=WARN msg=second path=a.go\n"
+	if b.String() != expected {
+		t.Errorf("Flush() の出力 = %q, expected %q", b.String(), expected)
+	}
+
+	// 2回目の Flush では何も出力しない
+	rec.Flush(context.Background())
+	if b.String() != expected {
+		t.Errorf("2回目の Flush() で出力されています: %q", b.String())
+	}
+}
+
+func TestOrDiscard(t *testing.T) {
+	OrDiscard(nil).Error("discarded")
+	if OrDiscard(nil).Enabled(context.Background(), slog.LevelError) {
+		t.Error("OrDiscard(nil) は何も出力しないはずです")
+	}
+}
+
+// Counter が出力レベルにかかわらず警告とエラーを数えることを検証
+func TestCounter(t *testing.T) {
+	tests := []struct {
+		strict           bool
+		errors, warnings int
+		failed           string
+		output           string
+	}{
+		{false, 1, 1, "missing.go", "level=ERROR msg=\"path not found\" path=missing.go\n"},
+		{true, 2, 0, "missing.go,logo.png", "level=ERROR msg=\"path not found\" path=missing.go\nlevel=ERROR msg=\"skipped binary file\" path=logo.png\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		target, _ := New(&b, FormatText, slog.LevelError)
+		c := NewCounter(target.Handler(), tt.strict)
+		l := slog.New(c)
+
+		l.Info("total")
+		l.Error("path not found", "path", "missing.go")
+		l.With("path", "logo.png").Warn("skipped binary file")
+
+		if c.Errors() != tt.errors || c.Warnings() != tt.warnings {
+			t.Errorf("strict=%v: (Errors, Warnings) = (%d, %d), expected (%d, %d)", tt.strict, c.Errors(), c.Warnings(), tt.errors, tt.warnings)
+		}
+		if got := strings.Join(c.Failed(), ","); got != tt.failed {
+			t.Errorf("strict=%v: Failed() = %q, expected %q", tt.strict, got, tt.failed)
+		}
+		if b.String() != tt.output {
+			t.Errorf("strict=%v: 出力 = %q, expected %q", tt.strict, b.String(), tt.output)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package markdown
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/your-org/code2md/internal/textenc"
+)
+
+// Format は、出力形式
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown" // Markdownのコードブロック (デフォルト)
+	FormatJSON     Format = "json"     // ファイルごとのオブジェクトを files 配列に並べた JSON
+	FormatXML      Format = "xml"      // ファイルごとの <file> 要素を並べた XML
+)
+
+// FormatForPath は、出力ファイルの拡張子から出力形式を返します
+// .json と .xml 以外 (.md など) は Markdown とします
+func FormatForPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".xml":
+		return FormatXML
+	}
+	return FormatMarkdown
+}
+
+// encoder は、変換結果を出力形式に応じて書き出します
+// end の total と files は、内容を出力したファイルの統計とその数で、
+// バイナリファイルのメタデータと生成コードの要約 (document には渡される) を含みません
+type encoder interface {
+	begin() error
+	document(d Document) error
+	end(total Stats, files int) error
+}
+
+// newEncoder は、format に対応する encoder を返します
+func newEncoder(w io.Writer, format Format) (encoder, error) {
+	switch format {
+	case FormatMarkdown, "":
+		return markdownEncoder{w}, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case FormatXML:
+		return xmlEncoder{w}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (expected markdown, json or xml)", format)
+}
+
+// markdownEncoder は、変換結果をコードブロックとして書き出します
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func (e markdownEncoder) begin() error              { return nil }
+func (e markdownEncoder) document(d Document) error { return WriteDocument(e.w, d) }
+func (e markdownEncoder) end(Stats, int) error      { return nil }
+
+// jsonFile は、JSON 形式で出力する1ファイル分の結果
+type jsonFile struct {
+	Path      string `json:"path"`
+	Lang      string `json:"lang"`
+	Encoding  string `json:"encoding,omitempty"`
+	Binary    bool   `json:"binary,omitempty"`
+	Generated bool   `json:"generated,omitempty"`
+	Omitted   int    `json:"omitted,omitempty"`
+	Lines     int    `json:"lines"`
+	Words     int    `json:"words"`
+	Chars     int    `json:"chars"`
+	Content   string `json:"content"`
+}
+
+// jsonEncoder は、変換結果を1つの JSON オブジェクトとして書き出します
+//
+//	{
+//	  "files": [
+//	    {"path": "main.go", "lang": "go", "lines": 3, ..., "content": "package main\n..."}
+//	  ],
+//	  "total": {"files": 1, "lines": 3, "words": 5, "chars": 30}
+//	}
+//
+// total は binary・generated のエントリを含まないため、files 配列の要素数より少ない場合があります
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) begin() error {
+	_, err := io.WriteString(e.w, "{\n  \"files\": [")
+	return err
+}
+
+func (e *jsonEncoder) document(d Document) error {
+	f := jsonFile{
+		Path:      d.RelPath,
+		Lang:      d.Lang,
+		Binary:    d.Binary,
+		Generated: d.Generated,
+		Omitted:   d.Omitted,
+		Lines:     d.Stats.Lines,
+		Words:     d.Stats.Words,
+		Chars:     d.Stats.Chars,
+		Content:   d.Content,
+	}
+	if d.Encoding != textenc.UTF8 {
+		f.Encoding = d.Encoding
+	}
+	// ソースコードの <, >, & をそのまま読めるよう、HTML向けのエスケープは行わない
+	var b strings.Builder
+	je := json.NewEncoder(&b)
+	je.SetEscapeHTML(false)
+	je.SetIndent("    ", "  ")
+	if err := je.Encode(f); err != nil {
+		return err
+	}
+	sep := "\n    "
+	if e.count > 0 {
+		sep = "," + sep
+	}
+	e.count++
+	_, err := fmt.Fprintf(e.w, "%s%s", sep, strings.TrimSuffix(b.String(), "\n"))
+	return err
+}
+
+func (e *jsonEncoder) end(total Stats, n int) error {
+	closing := "]"
+	if e.count > 0 {
+		closing = "\n  ]"
+	}
+	_, err := fmt.Fprintf(e.w, "%s,\n  \"total\": {\"files\": %d, \"lines\": %d, \"words\": %d, \"chars\": %d}\n}\n",
+		closing, n, total.Lines, total.Words, total.Chars)
+	return err
+}
+
+// xmlEncoder は、変換結果を <files> 要素の中の <file> 要素として書き出します
+// 内容は読みやすさのため CDATA セクションにそのまま記載します
+//
+//	<files>
+//	<file path="main.go" lang="go" lines="3" words="5" chars="30"><![CDATA[package main
+//	...
+//	]]></file>
+//	<total files="1" lines="3" words="5" chars="30"/>
+//	</files>
+type xmlEncoder struct {
+	w io.Writer
+}
+
+func (e xmlEncoder) begin() error {
+	_, err := io.WriteString(e.w, xml.Header+"<files>\n")
+	return err
+}
+
+func (e xmlEncoder) document(d Document) error {
+	var b strings.Builder
+	b.WriteString("<file")
+	attr := func(name, value string) {
+		b.WriteString(" " + name + `="`)
+		xml.EscapeText(&b, []byte(value))
+		b.WriteString(`"`)
+	}
+	attr("path", d.RelPath)
+	attr("lang", d.Lang)
+	if d.Encoding != "" && d.Encoding != textenc.UTF8 {
+		attr("encoding", d.Encoding)
+	}
+	if d.Binary {
+		attr("binary", "true")
+	}
+	if d.Generated {
+		attr("generated", "true")
+	}
+	if d.Omitted > 0 {
+		attr("omitted", strconv.Itoa(d.Omitted))
+	}
+	attr("lines", strconv.Itoa(d.Stats.Lines))
+	attr("words", strconv.Itoa(d.Stats.Words))
+	attr("chars", strconv.Itoa(d.Stats.Chars))
+	b.WriteString("><![CDATA[")
+	// "]]>" は CDATA セクションを分割して記載する
+	b.WriteString(strings.ReplaceAll(xmlChars(d.Content), "]]>", "]]]]><![CDATA[>"))
+	b.WriteString("]]></file>\n")
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e xmlEncoder) end(total Stats, n int) error {
+	_, err := fmt.Fprintf(e.w, "<total files=\"%d\" lines=\"%d\" words=\"%d\" chars=\"%d\"/>\n</files>\n",
+		n, total.Lines, total.Words, total.Chars)
+	return err
+}
+
+// xmlChars は、XML で使用できない文字 (制御文字や不正なUTF-8) を U+FFFD に置き換えます
+func xmlChars(s string) string {
+	valid := func(r rune) bool {
+		return r == '\t' || r == '\n' || r == '\r' ||
+			r >= 0x20 && r <= 0xD7FF ||
+			r >= 0xE000 && r <= 0xFFFD ||
+			r >= 0x10000 && r <= 0x10FFFF
+	}
+	for _, r := range s {
+		// 不正なUTF-8も RuneError として読み込まれるため、置き換えの対象とする
+		if !valid(r) || r == utf8.RuneError {
+			return strings.Map(func(r rune) rune {
+				if valid(r) {
+					return r
+				}
+				return utf8.RuneError
+			}, s)
+		}
+	}
+	return s
+}
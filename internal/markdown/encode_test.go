@@ -0,0 +1,138 @@
+package markdown
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Format
+	}{
+		{"bundle.md", FormatMarkdown},
+		{"bundle.txt", FormatMarkdown},
+		{"out/bundle.JSON", FormatJSON},
+		{"bundle.xml", FormatXML},
+		{"bundle", FormatMarkdown},
+	}
+	for _, tt := range tests {
+		if got := FormatForPath(tt.path); got != tt.expected {
+			t.Errorf("FormatForPath(%q) = %q, expected %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+// JSON と XML の出力を読み込み、元のファイルの内容が得られることを検証
+func TestPrintFormats(t *testing.T) {
+	main := writeTempFile(t, "main.go", "package main\n")
+	cdata := writeTempFile(t, "cdata.txt", "a ]]> b\x01 <c>\n")
+	png := writeTempFile(t, "logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	files := []File{{Path: main}, {Path: cdata}, {Path: png}}
+
+	type file struct {
+		Path    string `json:"path" xml:"path,attr"`
+		Lang    string `json:"lang" xml:"lang,attr"`
+		Lines   int    `json:"lines" xml:"lines,attr"`
+		Content string `json:"content" xml:",chardata"`
+	}
+	type total struct {
+		Files int `json:"files" xml:"files,attr"`
+		Lines int `json:"lines" xml:"lines,attr"`
+	}
+	type bundle struct {
+		Files []file `json:"files" xml:"file"`
+		Total total  `json:"total" xml:"total"`
+	}
+	expected := []file{
+		{"main.go", "go", 1, "package main\n"},
+		{"cdata.txt", "", 1, "a ]]> b\x01 <c>\n"},
+	}
+
+	for _, format := range []Format{FormatJSON, FormatXML} {
+		var buf strings.Builder
+		n, err := PrintCount(&buf, files, Options{Format: format})
+		if err != nil {
+			t.Fatalf("PrintCount(%s) エラー: %v", format, err)
+		}
+		if n != 2 {
+			t.Errorf("PrintCount(%s) = %d, expected 2", format, n)
+		}
+
+		// JSON では <, >, & を \u003c などにエスケープせずに出力する
+		if format == FormatJSON && !strings.Contains(buf.String(), `"a ]]> b\u0001 <c>\n"`) {
+			t.Errorf("JSON の内容がHTML向けにエスケープされています:\n%s", buf.String())
+		}
+
+		var got bundle
+		if format == FormatJSON {
+			err = json.Unmarshal([]byte(buf.String()), &got)
+		} else {
+			err = xml.Unmarshal([]byte(buf.String()), &got)
+			// XML で使用できない文字は置き換える
+			expected[1].Content = "a ]]> b� <c>\n"
+		}
+		if err != nil {
+			t.Fatalf("%s の出力を読み込めません: %v\n%s", format, err, buf.String())
+		}
+		if len(got.Files) != len(expected) {
+			t.Fatalf("%s: files = %+v, expected %+v", format, got.Files, expected)
+		}
+		for i, f := range got.Files {
+			e := expected[i]
+			// 一時ディレクトリのため、パスは実行ディレクトリからの相対パスの末尾で確認する
+			if filepath.Base(f.Path) != e.Path {
+				t.Errorf("%s: files[%d].path = %q, expected %q", format, i, f.Path, e.Path)
+			}
+			if f.Lang != e.Lang || f.Lines != e.Lines || f.Content != e.Content {
+				t.Errorf("%s: files[%d] = %+v, expected %+v", format, i, f, e)
+			}
+		}
+		if got.Total.Files != 2 || got.Total.Lines != 2 {
+			t.Errorf("%s: total = %+v", format, got.Total)
+		}
+	}
+
+	// 出力するファイルがない場合も、正しい形式で出力する
+	for _, format := range []Format{FormatJSON, FormatXML} {
+		var buf strings.Builder
+		n, err := PrintCount(&buf, []File{{Path: png}}, Options{Format: format})
+		if err != nil || n != 0 {
+			t.Fatalf("PrintCount(%s) = (%d, %v), expected 0", format, n, err)
+		}
+		var got bundle
+		if format == FormatJSON {
+			err = json.Unmarshal([]byte(buf.String()), &got)
+		} else {
+			err = xml.Unmarshal([]byte(buf.String()), &got)
+		}
+		if err != nil || len(got.Files) != 0 {
+			t.Errorf("%s: (%+v, %v)\n%s", format, got, err, buf.String())
+		}
+	}
+
+	// バイナリファイルのメタデータは files に含めるが、合計には含めない
+	for _, format := range []Format{FormatJSON, FormatXML} {
+		var buf strings.Builder
+		n, err := PrintCount(&buf, files, Options{Format: format, BinaryPlaceholders: true})
+		if err != nil || n != 3 {
+			t.Fatalf("PrintCount(%s) = (%d, %v), expected 3", format, n, err)
+		}
+		var got bundle
+		if format == FormatJSON {
+			err = json.Unmarshal([]byte(buf.String()), &got)
+		} else {
+			err = xml.Unmarshal([]byte(buf.String()), &got)
+		}
+		if err != nil || len(got.Files) != 3 || got.Total.Files != 2 {
+			t.Errorf("%s: (%+v, %v), expected 3 files and total 2", format, got, err)
+		}
+	}
+
+	if err := Print(&strings.Builder{}, files, Options{Format: "yaml"}); err == nil {
+		t.Error("不明な出力形式はエラーを返すべきです")
+	}
+}
@@ -0,0 +1,34 @@
+package markdown
+
+import (
+	"io/fs"
+	"os"
+)
+
+// File は、変換する1つのファイル
+// scan.File と同じ情報を持ちますが、探索とは独立して指定できます
+type File struct {
+	Path string // 表示に使用するパス (OS上のファイルは絶対パス、FS 内のファイルはそのパス)
+	Lang string // 言語タグ (空の場合はファイル名と内容から判定)
+	// Generated は、生成コードと判断した理由
+	// (Options.SummarizeGenerated の場合、内容の代わりに要約を出力します)
+	Generated string
+	FS        fs.FS  // ファイルを含むファイルシステム (nil の場合は Path をOS上のパスとして扱う)
+	Name      string // FS 内のパス
+}
+
+// Open は、ファイルを読み込み用に開きます
+func (f File) Open() (fs.File, error) {
+	if f.FS == nil {
+		return os.Open(f.Path)
+	}
+	return f.FS.Open(f.Name)
+}
+
+// Stat は、ファイルの情報を返します
+func (f File) Stat() (fs.FileInfo, error) {
+	if f.FS == nil {
+		return os.Stat(f.Path)
+	}
+	return fs.Stat(f.FS, f.Name)
+}
@@ -2,82 +2,532 @@ package markdown
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
-	"unicode/utf8"
 
 	"github.com/your-org/code2md/internal/lang"
+	"github.com/your-org/code2md/internal/lockfile"
+	"github.com/your-org/code2md/internal/logging"
+	"github.com/your-org/code2md/internal/sniff"
+	"github.com/your-org/code2md/internal/textenc"
+	"golang.org/x/text/transform"
 )
 
-// isBinary は、データがバイナリファイルかどうかを判断します
-// UTF-8として有効でないか、NUL文字を含む場合はバイナリとみなします
-func isBinary(data []byte) bool {
-	// UTF-8として有効でない場合はバイナリ
-	if !utf8.Valid(data) {
-		return true
+// Options は、Markdown出力の設定オプション
+type Options struct {
+	Truncate            Truncation     // すべてのファイルに適用する切り詰め設定
+	TruncateRules       []TruncateRule // パターンごとの切り詰め設定 (Truncate より優先)
+	IncludeGenerated    bool           // 生成コードや圧縮済みと判断したファイルも出力する
+	SummarizeGenerated  bool           // 生成コードや圧縮済みと判断したファイルをスキップする代わりに要約を出力する
+	Lockfiles           LockfileMode   // ロックファイルの出力方法
+	BinaryPlaceholders  bool           // バイナリファイルをスキップする代わりにメタデータを出力する
+	NotebookOutputLines int            // ノートブックのセル出力を残す行数 (0は出力しない)
+	SampleRows          int            // CSV・JSON配列・ログなどのデータファイルを要約する件数 (0は要約しない)
+	Normalize           Normalize      // BOM・改行コード・行末空白の正規化
+	Languages           lang.Mapping   // ユーザー定義の言語マッピング
+	Jobs                int            // 並列に読み込むファイル数 (0以下はCPU数)
+	Format              Format         // Print の出力形式 (空の場合は Markdown)
+	Logger              *slog.Logger   // 読み込んだファイルや警告の出力先 (nil の場合は出力しない)
+}
+
+// logOf は、診断メッセージの出力先を返します
+func logOf(opt Options) *slog.Logger {
+	return logging.OrDiscard(opt.Logger)
+}
+
+// Document は、1ファイル分の変換結果
+type Document struct {
+	Path      string // ファイルの絶対パス
+	RelPath   string // 実行ディレクトリからの相対パス (コードブロックの見出しに使用)
+	Lang      string // 言語タグ
+	Encoding  string // 元の文字コード
+	Binary    bool   // バイナリファイルのメタデータかどうか
+	Generated bool   // 生成コードの要約かどうか
+	Fence     string // コードブロックの区切り (空の場合は Content から決める)
+	Content   string // 出力する内容 (末尾の改行は1つ)
+	Omitted   int    // 切り詰めで省略した行数
+	Stats     Stats  // 出力する内容の統計情報
+	Size      int64  // 出力する内容のバイト数 (Measure で逐次読み込んだファイルでは Content は空)
+}
+
+// heading は、コードブロックの開始行 (区切りを除く) を返します
+func (d Document) heading() string {
+	h := d.Lang + ":" + d.RelPath
+	if d.Binary {
+		h += " binary"
+	}
+	if d.Generated {
+		h += " generated"
+	}
+	if d.Encoding != "" && d.Encoding != textenc.UTF8 {
+		h += " encoding=" + d.Encoding
 	}
+	return h
+}
 
-	// NUL文字を含む場合もバイナリとみなす
-	if bytes.IndexByte(data, 0) != -1 {
-		return true
+// WriteDocument は、変換結果をMarkdownコードブロックとして書き出します
+func WriteDocument(w io.Writer, d Document) error {
+	fence := d.Fence
+	if fence == "" {
+		fence = fenceFor(d.Content)
 	}
+	_, err := fmt.Fprintf(w, "%s%s\n%s%s\n\n", fence, d.heading(), d.Content, fence)
+	return err
+}
 
-	return false
+// countStats は、内容の行数・単語数・文字数を数えます
+func countStats(content string) Stats {
+	sw := &statsWriter{w: io.Discard}
+	io.WriteString(sw, content)
+	return sw.Stats()
 }
 
-// Print は、ファイルリストの内容をMarkdownコードブロック形式で出力します
-func Print(w io.Writer, files []string) error {
-	cwd, err := os.Getwd()
+// headOf は、言語の判定に使用する内容の先頭部分を返します
+func headOf(content string) string {
+	const size = 1024
+	if len(content) > size {
+		return content[:size]
+	}
+	return content
+}
+
+// openFile は、ファイルを開いて先頭部分から種類を判定します
+// 呼び出し側で返されたファイルを閉じる必要があります
+func openFile(file File) (fs.File, int64, []byte, sniff.Type, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, 0, nil, sniff.Type{}, err
+	}
+	stat, err := f.Stat()
 	if err != nil {
-		return fmt.Errorf("Failed to get current directory: %w", err)
+		f.Close()
+		return nil, 0, nil, sniff.Type{}, err
 	}
 
-	var totalWords, totalChars, totalLines int
+	prefix := make([]byte, sniff.PrefixSize)
+	n, err := io.ReadFull(f, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		f.Close()
+		return nil, 0, nil, sniff.Type{}, err
+	}
+	prefix = prefix[:n]
+	return f, stat.Size(), prefix, sniff.Detect(prefix), nil
+}
 
-	for _, filePath := range files {
-		// カレントディレクトリからの相対パスを取得
-		relPath, err := filepath.Rel(cwd, filePath)
-		if err != nil {
+// binaryDocument は、バイナリファイルをスキップするか、設定に応じてメタデータを返します
+// r はファイルの先頭から読み込めるものを渡します
+func binaryDocument(log *slog.Logger, r io.Reader, file File, relPath string, typ sniff.Type, opt Options) (Document, bool) {
+	if !opt.BinaryPlaceholders {
+		log.Warn("skipped binary file", "path", relPath, "type", typ.Name, "mime", typ.MIME, "hint", "use --binary-placeholders to include its metadata")
+		return Document{}, false
+	}
+
+	meta, err := binaryPlaceholder(r, relPath, typ)
+	if err != nil {
+		log.Error("could not read file", "path", relPath, "err", err)
+		return Document{}, false
+	}
+	log.Debug("printing metadata only", "path", relPath, "type", typ.Name)
+	return Document{Path: file.Path, RelPath: relPath, Lang: "text", Binary: true, Content: meta + "\n"}, true
+}
+
+// generatedDocument は、生成コード・圧縮済みと判断したファイルをスキップするか、設定に応じて要約を返します
+func generatedDocument(log *slog.Logger, file File, relPath, reason string, size int64, lines int, header string, opt Options) (Document, bool) {
+	if !opt.SummarizeGenerated {
+		// 内容による判定は名前から予想できないため、機密ファイルと同じく Info レベルで出力する
+		log.Info("ignored", "path", relPath, "reason", "generated: "+reason, "hint", "use --include-generated to include it")
+		return Document{}, false
+	}
+	log.Debug("printing summary only", "path", relPath, "reason", "generated: "+reason)
+	summary := generatedSummary(relPath, reason, size, lines, header)
+	return Document{Path: file.Path, RelPath: relPath, Lang: "text", Generated: true, Content: summary + "\n"}, true
+}
+
+// needsWholeFile は、内容全体を読み込んでから変換する必要があるファイルかどうかを返します
+// (ロックファイルの要約、ノートブックの変換、データファイルの要約)
+func needsWholeFile(filePath string, opt Options) bool {
+	switch {
+	case lockfile.IsLockfile(filepath.Base(filePath)):
+		return opt.Lockfiles != LockfileFull
+	case isNotebook(filePath):
+		return true
+	}
+	return opt.SampleRows > 0 && hasSampler(filePath)
+}
+
+// relativePaths は、各ファイルのカレントディレクトリからの相対パスを返します
+// OS上の絶対パスでないファイル (FS 内のファイル) はパスをそのまま使用します
+func relativePaths(files []File) ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get current directory: %w", err)
+	}
+
+	relPaths := make([]string, len(files))
+	for i, file := range files {
+		if !filepath.IsAbs(file.Path) {
+			relPaths[i] = file.Path
+			continue
+		}
+		if relPaths[i], err = filepath.Rel(cwd, file.Path); err != nil {
 			// 相対パス取得に失敗した場合は絶対パスを使用
-			relPath = filePath
+			relPaths[i] = file.Path
 		}
+	}
+	return relPaths, nil
+}
 
-		// ファイル内容を読み込み
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Error reading file '%s': %v. Skipping.\n", relPath, err)
-			continue
+// Convert は、ファイルリストの内容を変換し、files の順に fn へ渡します
+// ファイルは opt.Jobs 個のワーカーで並列に読み込み、大きなファイルも全体をメモリに読み込みます
+// ctx がキャンセルされた場合や fn がエラーを返した場合は、残りのファイルを変換せずにそのエラーを返します
+func Convert(ctx context.Context, files []File, opt Options, fn func(Document) error) error {
+	relPaths, err := relativePaths(files)
+	if err != nil {
+		return err
+	}
+
+	return renderOrdered(ctx, files, relPaths, opt, false, func(i int, r *rendered) error {
+		r.log.Flush(ctx)
+		if !r.ok {
+			return nil
 		}
+		return fn(r.doc)
+	})
+}
 
-		// バイナリファイルのチェック
-		if isBinary(data) {
-			fmt.Fprintf(os.Stderr, "Warning: File '%s' could not be read as UTF-8 text. Skipping.\n", relPath)
-			continue
+// Measure は、Convert と同様に変換しますが、逐次出力の対象となる大きなファイルは
+// 内容を保持せずに読み通し、Content を空にして Size と Stats のみを返します
+// (ロックファイルの要約、ノートブック、データファイルの要約は Convert と同じく全体を読み込みます)
+func Measure(ctx context.Context, files []File, opt Options, fn func(Document) error) error {
+	log := logOf(opt)
+	relPaths, err := relativePaths(files)
+	if err != nil {
+		return err
+	}
+
+	return renderOrdered(ctx, files, relPaths, opt, true, func(i int, r *rendered) error {
+		if r.stream {
+			var err error
+			if r.doc, r.ok, err = streamFile(io.Discard, log, files[i], relPaths[i], opt, true); err != nil {
+				return err
+			}
+		} else {
+			r.log.Flush(ctx)
+		}
+		if !r.ok {
+			return nil
 		}
+		return fn(r.doc)
+	})
+}
 
-		// 言語タグを取得
-		langTag := lang.Detect(filePath)
+// Print は、ファイルリストの内容を opt.Format の形式 (デフォルトはMarkdownコードブロック) で出力します
+// ファイルは opt.Jobs 個のワーカーで並列に読み込みますが、出力の順序は files の順に保たれます
+// 各ファイルは一度だけ読み込み、統計情報は出力しながら計算します
+// Markdown 以外の形式では、大きなファイルも全体をメモリに読み込みます
+func Print(w io.Writer, files []File, opt Options) error {
+	_, err := PrintCount(w, files, opt)
+	return err
+}
 
-		// ファイルの統計情報を計算
-		content := string(data)
-		lines := strings.Split(content, "\n")
-		words := len(strings.Fields(content))
-		chars := utf8.RuneCountInString(content)
+// PrintCount は、Print と同様に出力し、出力したファイルの数
+// (バイナリファイルのメタデータや生成コードの要約を含む) を返します
+func PrintCount(w io.Writer, files []File, opt Options) (int, error) {
+	log := logOf(opt)
+	enc, err := newEncoder(w, opt.Format)
+	if err != nil {
+		return 0, err
+	}
+	relPaths, err := relativePaths(files)
+	if err != nil {
+		return 0, err
+	}
+	if err := enc.begin(); err != nil {
+		return 0, err
+	}
 
-		// 統計を加算
-		totalLines += len(lines)
-		totalWords += words
-		totalChars += chars
+	var total Stats
+	var printed, written int
 
-		// Markdownコードブロックとして出力
-		fmt.Fprintf(w, "```%s:%s\n%s\n```\n\n", langTag, relPath, content)
+	stream := opt.Format == FormatMarkdown || opt.Format == ""
+	err = renderOrdered(context.Background(), files, relPaths, opt, stream, func(i int, r *rendered) error {
+		if r.stream {
+			var err error
+			if r.doc, r.ok, err = streamFile(w, log, files[i], relPaths[i], opt, false); err != nil {
+				return err
+			}
+		} else {
+			r.log.Flush(context.Background())
+			if r.ok {
+				if err := enc.document(r.doc); err != nil {
+					return err
+				}
+			}
+		}
+		if !r.ok {
+			return nil
+		}
+		written++
+		if r.doc.Binary || r.doc.Generated {
+			return nil
+		}
+		log.Log(context.Background(), logging.LevelTrace, "loaded", "path", relPaths[i], "lines", r.doc.Stats.Lines, "words", r.doc.Stats.Words, "chars", r.doc.Stats.Chars)
+
+		// 統計を加算
+		printed++
+		total.Lines += r.doc.Stats.Lines
+		total.Words += r.doc.Stats.Words
+		total.Chars += r.doc.Stats.Chars
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	if err := enc.end(total, printed); err != nil {
+		return written, err
 	}
 
 	// 最終的な統計情報を標準エラー出力に出力
-	fmt.Fprintf(os.Stderr, "Total: %d lines, %d words, %d characters\n", totalLines, totalWords, totalChars)
+	log.Info("total", "files", printed, "lines", total.Lines, "words", total.Words, "chars", total.Chars)
+
+	return written, nil
+}
+
+// convertFile は、1つのファイルを読み込んで変換します
+// 出力しない場合は2番目の戻り値に false を返します
+func convertFile(log *slog.Logger, file File, relPath string, opt Options) (Document, bool) {
+	f, _, prefix, typ, err := openFile(file)
+	if err != nil {
+		log.Error("could not read file", "path", relPath, "err", err)
+		return Document{}, false
+	}
+	defer f.Close()
+
+	// バイナリの場合は残りを読まない
+	if typ.Binary {
+		return binaryDocument(log, io.MultiReader(bytes.NewReader(prefix), f), file, relPath, typ, opt)
+	}
+
+	rest, err := io.ReadAll(f)
+	if err != nil {
+		log.Error("could not read file", "path", relPath, "err", err)
+		return Document{}, false
+	}
+	data := append(prefix, rest...)
+
+	// 文字コードを判定してUTF-8に変換 (バイナリファイルはスキップ)
+	content, enc, err := textenc.Decode(data)
+	if err != nil {
+		return binaryDocument(log, bytes.NewReader(data), file, relPath, sniff.Unknown, opt)
+	}
+	content = normalize(content, opt.Normalize)
+	if enc != textenc.UTF8 {
+		log.Debug("decoded", "path", relPath, "encoding", enc)
+	}
+
+	// 言語タグを取得
+	// (.gitattributes の linguist-language > ユーザー定義 > 組み込みの判定)
+	langTag := lang.Canonical(file.Lang)
+	if langTag == "" {
+		langTag = opt.Languages.Detect(file.Path, []byte(headOf(content)))
+	}
+
+	// 探索時にファイル名や .gitattributes から生成コードと判断したファイルは要約する
+	if file.Generated != "" {
+		return generatedDocument(log, file, relPath, file.Generated, int64(len(data)), countStats(content).Lines, generatedHeaderLine(content), opt)
+	}
+
+	switch {
+	case lockfile.IsLockfile(filepath.Base(file.Path)):
+		// ロックファイルは生成コードの判定より先に扱う
+		var skip bool
+		if content, langTag, skip = renderLockfile(log, relPath, content, langTag, opt.Lockfiles); skip {
+			return Document{}, false
+		}
+	case isNotebook(file.Path):
+		// ノートブックはコードブロックを含むMarkdownに変換する
+		rendered, err := renderNotebook(content, opt.NotebookOutputLines)
+		if err != nil {
+			log.Warn("could not convert notebook; printing it as JSON", "path", relPath, "err", err)
+			break
+		}
+		content, langTag = rendered, "markdown"
+	case opt.SampleRows > 0 && hasSampler(file.Path):
+		// データファイルは先頭の数件に要約する
+		sample, _ := samplerFor(file.Path)
+		sampled, note, err := sample(content, opt.SampleRows)
+		if err != nil {
+			log.Warn("could not sample data file; printing it in full", "path", relPath, "err", err)
+			break
+		}
+		if note != "" {
+			log.Debug("sampled", "path", relPath, "note", note)
+		}
+		content = sampled
+	case !opt.IncludeGenerated:
+		// 生成コード・圧縮済みファイルのチェック
+		if reason, ok := generatedReason(file.Path, content); ok {
+			return generatedDocument(log, file, relPath, reason, int64(len(data)), countStats(content).Lines, generatedHeaderLine(content), opt)
+		}
+	}
+
+	// 長いファイルの切り詰め
+	content, omitted := truncateLines(content, truncationFor(relPath, opt))
+	if omitted > 0 {
+		log.Debug("truncated", "path", relPath, "omitted", omitted)
+	}
+
+	// 閉じフェンスの前に空行が入らないよう、末尾の改行を1つにそろえる
+	content = withFinalNewline(content)
+
+	// 内容に含まれるバッククォートの並びより長い区切りを使用する
+	fence := fenceFor(content)
+
+	return Document{
+		Path:     file.Path,
+		RelPath:  relPath,
+		Lang:     langTag,
+		Encoding: enc,
+		Fence:    fence,
+		Content:  content,
+		Omitted:  omitted,
+		Stats:    countStats(content),
+	}, true
+}
 
-	return nil
+// streamFile は、大きなテキストファイルを全体を読み込まずにコードブロックとして w に書き出します
+// 文字コード・言語・生成コードの判定には先頭部分のみを使用します
+// 区切りを決めるために一度内容を読み通し、開き直してから書き出します
+// 先頭部分でUTF-8と判定した後に不正なバイトが現れた場合は U+FFFD に置き換えて警告します
+// 返す Document には内容を含みません。w への書き込みに失敗した場合はエラーを返します
+// bare が true の場合は、区切りと見出しを書き出さずに内容だけを w に書き出します (Measure)
+func streamFile(w io.Writer, log *slog.Logger, file File, relPath string, opt Options, bare bool) (Document, bool, error) {
+	f, size, prefix, typ, err := openFile(file)
+	if err != nil {
+		log.Error("could not read file", "path", relPath, "err", err)
+		return Document{}, false, nil
+	}
+	// 区切りを決めた後に開き直すため、閉じる際は最後に開いたファイルを参照する
+	defer func() { f.Close() }()
+
+	if typ.Binary {
+		doc, ok := binaryDocument(log, io.MultiReader(bytes.NewReader(prefix), f), file, relPath, typ, opt)
+		if ok {
+			doc.Size = int64(len(doc.Content))
+			err = WriteDocument(w, doc)
+		}
+		return doc, ok, err
+	}
+
+	// 判定に使用する先頭部分を読み足す
+	head := make([]byte, streamBufferSize)
+	n := copy(head, prefix)
+	m, err := io.ReadFull(f, head[n:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		log.Error("could not read file", "path", relPath, "err", err)
+		return Document{}, false, nil
+	}
+	head = head[:n+m]
+
+	enc := textenc.DetectPrefix(head)
+	if enc == "" {
+		doc, ok := binaryDocument(log, io.MultiReader(bytes.NewReader(head), f), file, relPath, sniff.Unknown, opt)
+		if ok {
+			doc.Size = int64(len(doc.Content))
+			err = WriteDocument(w, doc)
+		}
+		return doc, ok, err
+	}
+	decodedHead, _ := io.ReadAll(textenc.NewReader(bytes.NewReader(head), enc))
+	sample := normalize(string(decodedHead), opt.Normalize)
+	if enc != textenc.UTF8 {
+		log.Debug("decoded", "path", relPath, "encoding", enc)
+	}
+
+	doc := Document{Path: file.Path, RelPath: relPath, Lang: lang.Canonical(file.Lang), Encoding: enc}
+	if doc.Lang == "" {
+		doc.Lang = opt.Languages.Detect(file.Path, []byte(headOf(sample)))
+	}
+
+	// 生成コード・圧縮済みファイルのチェック (ロックファイルは対象外)
+	reason := file.Generated
+	if reason == "" && !opt.IncludeGenerated && !lockfile.IsLockfile(filepath.Base(file.Path)) {
+		reason, _ = generatedReason(file.Path, sample)
+	}
+	if reason != "" {
+		lines := 0
+		if opt.SummarizeGenerated {
+			// 要約に記載する行数は、全体を読み込まずに数える
+			sw := &statsWriter{w: io.Discard}
+			if _, err := io.Copy(sw, textenc.NewReader(io.MultiReader(bytes.NewReader(head), f), enc)); err != nil {
+				log.Error("could not read file", "path", relPath, "err", err)
+				return Document{}, false, nil
+			}
+			lines = sw.Stats().Lines
+		}
+		doc, ok := generatedDocument(log, file, relPath, reason, size, lines, generatedHeaderLine(sample), opt)
+		if ok {
+			doc.Size = int64(len(doc.Content))
+			err = WriteDocument(w, doc)
+		}
+		return doc, ok, err
+	}
+
+	var r io.Reader = io.MultiReader(bytes.NewReader(head), f)
+	if !bare {
+		// 区切りは内容全体に含まれるバッククォートの並びから決めるため、書き出す前に一度読み通す
+		// (先頭部分より後ろや、切り詰めで残す末尾の行にコードブロックがあっても閉じないように)
+		fences := &fenceScanner{}
+		if _, err := io.Copy(fences, textenc.NewReader(r, enc)); err != nil {
+			log.Error("could not read file", "path", relPath, "err", err)
+			return Document{}, false, nil
+		}
+		doc.Fence = fences.fence()
+		f.Close()
+		if f, err = file.Open(); err != nil {
+			log.Error("could not read file", "path", relPath, "err", err)
+			return Document{}, false, nil
+		}
+		r = f
+
+		// Markdownコードブロックとして出力
+		if _, err := fmt.Fprintf(w, "%s%s\n", doc.Fence, doc.heading()); err != nil {
+			return doc, false, err
+		}
+	}
+	sw := &statsWriter{w: w}
+	var replacer *utf8Replacer
+	if enc == textenc.UTF8 {
+		// 先頭部分だけで判定しているため、後半の不正なバイトは置き換えて警告する
+		replacer = &utf8Replacer{}
+		r = transform.NewReader(r, replacer)
+	} else {
+		r = textenc.NewReader(r, enc)
+	}
+	doc.Omitted, err = streamText(sw, r, opt.Normalize, truncationFor(relPath, opt))
+	doc.Stats, doc.Size = sw.Stats(), sw.size
+	if sw.err != nil {
+		return doc, false, sw.err
+	}
+	if !bare {
+		if _, err := fmt.Fprintf(w, "%s\n\n", doc.Fence); err != nil {
+			return doc, false, err
+		}
+	}
+	if err != nil {
+		// 書き込みのエラーは上で返しているため、ここでは読み込みのエラー
+		log.Error("could not read file; output may be incomplete", "path", relPath, "err", err)
+	}
+	if replacer != nil && replacer.invalid > 0 {
+		log.Warn("replaced invalid UTF-8 bytes", "path", relPath, "count", replacer.invalid, "hint", "the file may mix encodings")
+	}
+	if doc.Omitted > 0 {
+		log.Debug("truncated", "path", relPath, "omitted", doc.Omitted)
+	}
+	return doc, true, nil
 }
@@ -0,0 +1,123 @@
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTempFile は、テスト用の一時ファイルを作成してそのパスを返します
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+	return path
+}
+
+func TestPrintLockfiles(t *testing.T) {
+	path := writeTempFile(t, "Cargo.lock", "# This file is automatically @generated by Cargo.\n\n[[package]]\nname = \"serde\"\nversion = \"1.0.190\"\n")
+
+	tests := []struct {
+		mode     LockfileMode
+		expected string
+	}{
+		{LockfileFull, "[[package]]\nname = \"serde\""},
+		{LockfileSummary, "```text:"},
+		{LockfileSummary, "# lockfile summary (Cargo.lock): 1 packages\nserde 1.0.190\n"},
+		{LockfileOmit, ""},
+	}
+
+	for _, tt := range tests {
+		var buf strings.Builder
+		if err := Print(&buf, []File{{Path: path}}, Options{Lockfiles: tt.mode}); err != nil {
+			t.Fatalf("Print() エラー: %v", err)
+		}
+		if tt.expected == "" {
+			if buf.Len() != 0 {
+				t.Errorf("mode %s: 出力されるべきではありません:\n%s", tt.mode, buf.String())
+			}
+			continue
+		}
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("mode %s: 出力に %q が含まれていません:\n%s", tt.mode, tt.expected, buf.String())
+		}
+	}
+}
+
+// 要約できないロックファイルは、元の言語タグのまま出力する
+func TestPrintLockfileSummaryFallback(t *testing.T) {
+	path := writeTempFile(t, "package-lock.json", "{\"packages\": [\n")
+
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: path}}, Options{Lockfiles: LockfileSummary}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if !strings.Contains(buf.String(), "```json:") {
+		t.Errorf("要約できないロックファイルの言語タグが変更されています:\n%s", buf.String())
+	}
+}
+
+func TestPrintDecodesLegacyEncodings(t *testing.T) {
+	// "こんにちは" を Shift_JIS で記述したファイル
+	path := writeTempFile(t, "hello.c", "// \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n")
+
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: path}}, Options{}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if !strings.Contains(buf.String(), "hello.c encoding=Shift_JIS\n// こんにちは\n") {
+		t.Errorf("Shift_JIS のファイルがUTF-8に変換されていません:\n%s", buf.String())
+	}
+}
+
+func TestPrintSkipsBinaryFiles(t *testing.T) {
+	png := writeTempFile(t, "logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	text := writeTempFile(t, "main.go", "package main\n")
+
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: png}, {Path: text}}, Options{}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if strings.Contains(buf.String(), "logo.png") {
+		t.Errorf("バイナリファイルが出力されています:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "package main") {
+		t.Errorf("テキストファイルが出力されていません:\n%s", buf.String())
+	}
+}
+
+func TestPrintBinaryPlaceholders(t *testing.T) {
+	// 2x3 ピクセルの PNG 画像
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 2, 3))); err != nil {
+		t.Fatalf("PNG の作成に失敗: %v", err)
+	}
+	path := writeTempFile(t, "logo.png", img.String())
+
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: path}}, Options{BinaryPlaceholders: true}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+
+	sum := sha256.Sum256(img.Bytes())
+	for _, expected := range []string{
+		"logo.png binary\n",
+		fmt.Sprintf("size: %d bytes\n", img.Len()),
+		"type: PNG image (image/png)\n",
+		"dimensions: 2x3\n",
+		"sha256: " + hex.EncodeToString(sum[:]) + "\n```",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("出力に %q が含まれていません:\n%s", expected, buf.String())
+		}
+	}
+}
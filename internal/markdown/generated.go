@@ -0,0 +1,106 @@
+package markdown
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 生成コードであることを示すヘッダーの検出に使用する先頭の行数
+const generatedHeaderLines = 10
+
+// generatedComment は、コメント行の開始 (//, #, --, ;, /*, *, <!--) に一致するパターン
+const generatedComment = `^\s*(?://+|#+|--|;+|/\*+|\*|<!--)\s*`
+
+// 生成コードのヘッダーとみなすパターン
+// Go の規約 (https://go.dev/s/generatedcode) と、その他の一般的な生成ツールがコメント行に記載する定型文
+// 手書きのコメント ("Generated reports live in out/; do not edit them" など) と区別するため、
+// 生成ツールの決まった言い回しのみを対象とします
+var generatedHeaders = []*regexp.Regexp{
+	regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`),
+	// sqlc や protoc のプラグインなど、Go 以外の言語で同じ規約に従うもの
+	regexp.MustCompile(generatedComment + `Code generated .* DO NOT EDIT\b`),
+	// "This file was generated by X. DO NOT EDIT." のように、大文字の DO NOT EDIT を伴うもの
+	regexp.MustCompile(generatedComment + `(?:This (?:file|code) (?:is|was|has been) )?(?:auto-?|automatically )?[Gg]enerated (?:by|from) .*\bDO NOT EDIT\b`),
+	// "This file is autogenerated by pip-compile"、"Autogenerated by Thrift Compiler" など
+	regexp.MustCompile(generatedComment + `(?:This (?:file|code) (?:is|was|has been) )?(?i:auto-?generated|automatically generated) (?:by|from|file|code)\b`),
+	regexp.MustCompile(generatedComment + `Generated by the protocol buffer compiler\.`),
+	// Facebook などのツールが使用する @generated (Cargo.lock の "automatically @generated by Cargo" を含む)
+	regexp.MustCompile(generatedComment + `(?:.*\s)?@generated\b`),
+}
+
+// 圧縮済み (minified) の判定の対象とする拡張子
+// JSON などのデータファイルは1行でも通常の内容のため対象外とする
+var minifiableExts = map[string]bool{
+	".js":  true,
+	".mjs": true,
+	".cjs": true,
+	".css": true,
+}
+
+// 圧縮済み (minified) とみなす行の長さの基準
+const (
+	minifiedMinBytes   = 1024 // これより小さいファイルは判定しない
+	minifiedAvgLineLen = 500  // 平均行長がこれを超える場合
+	minifiedMaxLineLen = 5000 // 1行でもこれを超える場合
+)
+
+// generatedReason は、内容から生成コードや圧縮済みファイルと判断される場合にその理由を返します
+// 圧縮済みかどうかは、name の拡張子が JavaScript や CSS の場合のみ判定します
+func generatedReason(name, content string) (string, bool) {
+	if generatedHeaderLine(content) != "" {
+		return "generated header", true
+	}
+
+	// 行の長さの分布から圧縮済みファイルを判定
+	if len(content) < minifiedMinBytes || !minifiableExts[strings.ToLower(filepath.Ext(name))] {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, len(line))
+	}
+	if len(content)/len(lines) > minifiedAvgLineLen || longest > minifiedMaxLineLen {
+		return "minified", true
+	}
+	return "", false
+}
+
+// generatedHeaderLine は、先頭数行のうち生成コードのヘッダーに一致する行を返します
+// 一致する行がない場合は空文字を返します
+func generatedHeaderLine(content string) string {
+	head := content
+	for i, n := 0, 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			if n++; n == generatedHeaderLines {
+				head = content[:i]
+				break
+			}
+		}
+	}
+	for _, line := range strings.Split(head, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		for _, re := range generatedHeaders {
+			if re.MatchString(line) {
+				return line
+			}
+		}
+	}
+	return ""
+}
+
+// generatedSummary は、生成コードの代わりに出力する要約を生成します
+// (パス、判定の理由、サイズ、行数、ヘッダー)
+func generatedSummary(relPath, reason string, size int64, lines int, header string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\n", relPath)
+	fmt.Fprintf(&b, "generated: %s\n", reason)
+	fmt.Fprintf(&b, "size: %d bytes\n", size)
+	fmt.Fprintf(&b, "lines: %d", lines)
+	if header != "" {
+		fmt.Fprintf(&b, "\nheader: %s", header)
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package markdown
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedReason(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		generated bool
+	}{
+		{"Go生成コード", "api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage pb\n", true},
+		{"@generated", "index.ts", "/**\n * @generated\n */\nexport const x = 1;\n", true},
+		{"autogenerated", "requirements.txt", "# This file is autogenerated by pip-compile\nrequests==2.0\n", true},
+		{"他の言語の生成コード", "schema.py", "# Code generated by sqlc. DO NOT EDIT.\nimport x\n", true},
+		{"protoc の Python 出力", "api_pb2.py", "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\nimport x\n", true},
+		{"generated by と DO NOT EDIT", "schema.ts", "// This file was generated by openapi-generator. DO NOT EDIT.\nexport {}\n", true},
+		{"Thrift", "gen.py", "#\n# Autogenerated by Thrift Compiler (0.19.0)\n#\n", true},
+		{"手書きのコメント", "report.py", "# Generated reports live in out/; do not edit them by hand.\nimport os\n", false},
+		{"小文字の do not edit", "notes.sh", "# generated by hand, do not edit casually\necho\n", false},
+		{"通常のコード", "main.go", "package main\n\nfunc main() {}\n", false},
+		{"ヘッダーより後のマーカーは無視", "main.go", strings.Repeat("x\n", 20) + "// Code generated by x. DO NOT EDIT.\n", false},
+		{"コメント以外のマーカーは無視", "main.go", "package main\n\nconst warning = \"DO NOT EDIT\"\n", false},
+		{"生成コード以外の DO NOT EDIT", "config.go", "// DO NOT EDIT without updating the docs\npackage config\n", false},
+		{"圧縮済みJS", "app.js", "!function(){" + strings.Repeat("var a=1;", 1000) + "}();\n", true},
+		{"長い行が多いCSS", "style.css", strings.Repeat(strings.Repeat("a", 600)+"\n", 5), true},
+		{"1行のJSONは対象外", "fixture.json", "{\"items\":[" + strings.Repeat("{\"id\":1,\"name\":\"item\"},", 300) + "{}]}\n", false},
+		{"長い行が多いデータファイル", "data.csv", strings.Repeat(strings.Repeat("a,", 300)+"\n", 5), false},
+		{"通常の長さの行", "app.js", strings.Repeat("const value = compute(input);\n", 200), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := generatedReason(tt.file, tt.content)
+			if ok != tt.generated {
+				t.Errorf("generatedReason() = (%q, %v), expected %v", reason, ok, tt.generated)
+			}
+		})
+	}
+}
+
+// SummarizeGenerated で生成コードの代わりに要約を出力することを検証
+func TestPrintGeneratedSummary(t *testing.T) {
+	header := "// Code generated by protoc-gen-go. DO NOT EDIT."
+	small := writeTempFile(t, "api.pb.go", header+"\n\npackage pb\n")
+	large := writeTempFile(t, "big.go", header+"\n"+strings.Repeat("var x = 1\n", int(streamThreshold/10+1)))
+	named := writeTempFile(t, "app.min.js", "var a=1;\n")
+
+	for _, path := range []string{small, large} {
+		var buf strings.Builder
+		n, err := PrintCount(&buf, []File{{Path: path}}, Options{SummarizeGenerated: true})
+		if err != nil || n != 1 {
+			t.Fatalf("PrintCount(%s) = (%d, %v), expected 1", filepath.Base(path), n, err)
+		}
+		for _, expected := range []string{
+			filepath.Base(path) + " generated\n",
+			"generated: generated header\n",
+			"header: " + header + "\n```",
+		} {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("%s: 出力に %q が含まれていません:\n%s", filepath.Base(path), expected, buf.String())
+			}
+		}
+		if strings.Contains(buf.String(), "package pb") || strings.Contains(buf.String(), "var x") {
+			t.Errorf("%s: 生成コードの内容が出力されています", filepath.Base(path))
+		}
+	}
+
+	// 探索時に判断した理由をそのまま使う
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: named, Generated: "minified"}}, Options{SummarizeGenerated: true}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if !strings.Contains(buf.String(), "generated: minified\nsize: 9 bytes\nlines: 1\n") {
+		t.Errorf("要約が正しくありません:\n%s", buf.String())
+	}
+
+	// SummarizeGenerated なしではスキップする
+	buf.Reset()
+	if n, err := PrintCount(&buf, []File{{Path: small}}, Options{}); err != nil || n != 0 || buf.Len() != 0 {
+		t.Errorf("PrintCount() = (%d, %v), expected skipped:\n%s", n, err, buf.String())
+	}
+}
@@ -0,0 +1,47 @@
+package markdown
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/your-org/code2md/internal/lockfile"
+)
+
+// LockfileMode は、ロックファイル (go.sum, package-lock.json など) の出力方法
+type LockfileMode string
+
+const (
+	LockfileFull    LockfileMode = "full"    // 内容をそのまま出力 (デフォルト)
+	LockfileSummary LockfileMode = "summary" // パッケージとバージョンの要約を出力
+	LockfileOmit    LockfileMode = "omit"    // 出力しない
+)
+
+// ParseLockfileMode は、文字列を LockfileMode に変換します
+func ParseLockfileMode(s string) (LockfileMode, error) {
+	switch m := LockfileMode(s); m {
+	case "", LockfileFull:
+		return LockfileFull, nil
+	case LockfileSummary, LockfileOmit:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid lockfile mode %q (expected full, summary or omit)", s)
+}
+
+// renderLockfile は、ロックファイルを設定に従って変換し、内容と言語タグを返します
+// 出力しない場合は3番目の戻り値に true を返し、要約できない場合は内容と言語タグをそのまま返します
+func renderLockfile(log *slog.Logger, relPath, content, langTag string, mode LockfileMode) (string, string, bool) {
+	switch mode {
+	case LockfileOmit:
+		log.Debug("ignored", "path", relPath, "reason", "lockfile")
+		return "", langTag, true
+	case LockfileSummary:
+		s, err := lockfile.Summarize(filepath.Base(relPath), []byte(content))
+		if err != nil {
+			log.Warn("could not summarize lockfile; printing it in full", "path", relPath, "err", err)
+			return content, langTag, false
+		}
+		return s.String(), "text", false
+	}
+	return content, langTag, false
+}
@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"strings"
+)
+
+// Normalize は、出力前にテキストへ適用する正規化の設定
+type Normalize struct {
+	StripBOM          bool // 先頭の BOM (U+FEFF) を取り除く
+	CRLFToLF          bool // 改行コード CRLF / CR を LF に変換する
+	TrimTrailingSpace bool // 各行末の空白とタブを取り除く
+}
+
+// normalize は、設定に従ってテキストを正規化します
+func normalize(content string, n Normalize) string {
+	if n.StripBOM {
+		content = strings.TrimPrefix(content, "\ufeff")
+	}
+	if n.CRLFToLF {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+		content = strings.ReplaceAll(content, "\r", "\n")
+	}
+	if n.TrimTrailingSpace {
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+		content = strings.Join(lines, "\n")
+	}
+	return content
+}
+
+// withFinalNewline は、末尾の改行をちょうど1つにそろえます
+// 改行コードを変換しない場合に備え、末尾の改行が CRLF であれば CRLF を残します
+// 空の内容はそのまま返します
+func withFinalNewline(content string) string {
+	trimmed := strings.TrimRight(content, "\r\n")
+	if trimmed == "" {
+		return ""
+	}
+	return trimmed + lineEnding(content[len(trimmed):])
+}
+
+// lineEnding は、末尾の改行の並びから最終行の改行コードを返します (CRLF 以外は LF)
+func lineEnding(newlines string) string {
+	if strings.HasPrefix(newlines, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,63 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	content := "\ufeffline1  \r\nline2\t\r\nline3\r"
+
+	tests := []struct {
+		name     string
+		opt      Normalize
+		expected string
+	}{
+		{"正規化なし", Normalize{}, content},
+		{"BOM除去", Normalize{StripBOM: true}, "line1  \r\nline2\t\r\nline3\r"},
+		{"改行コード変換", Normalize{CRLFToLF: true}, "\ufeffline1  \nline2\t\nline3\n"},
+		{"すべて", Normalize{StripBOM: true, CRLFToLF: true, TrimTrailingSpace: true}, "line1\nline2\nline3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalize(content, tt.opt); got != tt.expected {
+				t.Errorf("normalize() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWithFinalNewline(t *testing.T) {
+	tests := map[string]string{
+		"":           "",
+		"\n\n":       "",
+		"a":          "a\n",
+		"a\n":        "a\n",
+		"a\n\n\n":    "a\n",
+		"a\r\n":      "a\r\n",
+		"a\r\n\r\n":  "a\r\n",
+		"a\r":        "a\n",
+		"a\n\nb\n\n": "a\n\nb\n",
+	}
+	for input, expected := range tests {
+		if got := withFinalNewline(input); got != expected {
+			t.Errorf("withFinalNewline(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+// 末尾に改行があるファイルでも閉じフェンスの前に空行が入らないことを検証
+func TestPrintSingleTrailingNewline(t *testing.T) {
+	for _, content := range []string{"package main\n", "package main"} {
+		path := writeTempFile(t, "main.go", content)
+
+		var buf strings.Builder
+		if err := Print(&buf, []File{{Path: path}}, Options{}); err != nil {
+			t.Fatalf("Print() エラー: %v", err)
+		}
+		if !strings.HasSuffix(buf.String(), "main.go\npackage main\n```\n\n") {
+			t.Errorf("Print() = %q", buf.String())
+		}
+	}
+}
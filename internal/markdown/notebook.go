@@ -0,0 +1,151 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// notebook は、Jupyter ノートブック (nbformat 4) のうち変換に必要な部分
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   multilineString  `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Text       multilineString            `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	EName      string                     `json:"ename"`
+	EValue     string                     `json:"evalue"`
+}
+
+// multilineString は、文字列または文字列の配列として記録されるノートブックのテキスト
+type multilineString string
+
+func (m *multilineString) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*m = multilineString(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(b, &lines); err != nil {
+		return err
+	}
+	*m = multilineString(strings.Join(lines, ""))
+	return nil
+}
+
+// isNotebook は、パスが Jupyter ノートブックかどうかを返します
+func isNotebook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ipynb")
+}
+
+// renderNotebook は、ノートブックをMarkdownに変換します
+// コードセルは言語タグ付きのコードブロック、Markdownセルはそのままの文章として出力し、
+// セルの出力は outputLines 行まで残します (0 の場合は出力しない)
+func renderNotebook(content string, outputLines int) (string, error) {
+	var nb notebook
+	if err := json.Unmarshal([]byte(content), &nb); err != nil {
+		return "", err
+	}
+	if nb.Cells == nil {
+		return "", fmt.Errorf("no cells found (only nbformat 4 is supported)")
+	}
+
+	langTag := nb.Metadata.LanguageInfo.Name
+	if langTag == "" {
+		langTag = nb.Metadata.KernelSpec.Language
+	}
+
+	var parts []string
+	for _, cell := range nb.Cells {
+		source := strings.TrimRight(string(cell.Source), "\n")
+		switch cell.CellType {
+		case "markdown":
+			if source != "" {
+				parts = append(parts, source)
+			}
+		case "code":
+			if source == "" {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("```%s\n%s\n```", langTag, source))
+			if outputLines > 0 {
+				if out := renderNotebookOutputs(cell.Outputs, outputLines); out != "" {
+					parts = append(parts, fmt.Sprintf("Output:\n\n```text\n%s\n```", out))
+				}
+			}
+		default:
+			// raw セルはそのままコードブロックとして出力
+			if source != "" {
+				parts = append(parts, fmt.Sprintf("```\n%s\n```", source))
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// renderNotebookOutputs は、セルの出力をテキストに変換し maxLines 行に切り詰めます
+func renderNotebookOutputs(outputs []notebookOutput, maxLines int) string {
+	var texts []string
+	for _, o := range outputs {
+		switch o.OutputType {
+		case "stream":
+			texts = append(texts, strings.TrimRight(string(o.Text), "\n"))
+		case "error":
+			texts = append(texts, fmt.Sprintf("%s: %s", o.EName, o.EValue))
+		case "execute_result", "display_data":
+			if raw, ok := o.Data["text/plain"]; ok {
+				var text multilineString
+				if err := json.Unmarshal(raw, &text); err == nil {
+					texts = append(texts, strings.TrimRight(string(text), "\n"))
+					continue
+				}
+			}
+			// テキスト表現がない出力 (画像など) は種類だけを記録
+			var kinds []string
+			for k := range o.Data {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+			if len(kinds) > 0 {
+				texts = append(texts, fmt.Sprintf("[%s output omitted]", strings.Join(kinds, ", ")))
+			}
+		}
+	}
+
+	out := strings.Join(texts, "\n")
+	if out == "" {
+		return ""
+	}
+	out, _ = truncateLines(out, Truncation{Head: maxLines})
+	return out
+}
+
+// fenceFor は、内容に含まれるどのバッククォートの連続よりも長いコードフェンスを返します
+func fenceFor(content string) string {
+	longest := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimLeft(line, " ")
+		n := len(line) - len(strings.TrimLeft(line, "`"))
+		longest = max(longest, n)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
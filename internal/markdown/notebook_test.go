@@ -0,0 +1,73 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# 分析\n", "データを読み込みます"]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "source": "import pandas as pd\ndf = pd.read_csv(\"data.csv\")\ndf.head()",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["line1\n", "line2\n", "line3\n"]},
+    {"output_type": "display_data", "data": {"image/png": "iVBORw0KGgo="}, "metadata": {}}
+   ]},
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "source": [], "outputs": []}
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+func TestRenderNotebook(t *testing.T) {
+	got, err := renderNotebook(testNotebook, 0)
+	if err != nil {
+		t.Fatalf("renderNotebook() エラー: %v", err)
+	}
+	expected := "# 分析\nデータを読み込みます\n\n```python\nimport pandas as pd\ndf = pd.read_csv(\"data.csv\")\ndf.head()\n```"
+	if got != expected {
+		t.Errorf("renderNotebook() = %q, expected %q", got, expected)
+	}
+
+	// 出力を2行まで残す
+	got, err = renderNotebook(testNotebook, 2)
+	if err != nil {
+		t.Fatalf("renderNotebook() エラー: %v", err)
+	}
+	if !strings.HasSuffix(got, "Output:\n\n```text\nline1\nline2\n... 2 lines omitted ...\n```") {
+		t.Errorf("renderNotebook() の出力が切り詰められていません: %q", got)
+	}
+
+	if _, err := renderNotebook(`{"nbformat": 3, "worksheets": []}`, 0); err == nil {
+		t.Error("nbformat 3 ではエラーを返すべきです")
+	}
+}
+
+func TestFenceFor(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"plain text", "```"},
+		{"```go\ncode\n```", "````"},
+		{"  ````\nnested\n  ````", "`````"},
+	}
+	for _, tt := range tests {
+		if got := fenceFor(tt.content); got != tt.expected {
+			t.Errorf("fenceFor(%q) = %q, expected %q", tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestPrintNotebook(t *testing.T) {
+	path := writeTempFile(t, "analysis.ipynb", testNotebook)
+
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: path}}, Options{}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "````markdown:") || !strings.HasSuffix(buf.String(), "\n````\n\n") {
+		t.Errorf("ノートブックが外側のフェンスで囲まれていません:\n%s", buf.String())
+	}
+}
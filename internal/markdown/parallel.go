@@ -0,0 +1,95 @@
+package markdown
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/your-org/code2md/internal/logging"
+)
+
+// 先行して変換しておく結果の数 (ワーカー数に対する倍率)
+const aheadPerJob = 4
+
+// rendered は、ワーカーが変換した1ファイル分の結果
+type rendered struct {
+	doc    Document
+	log    *logging.Recorder // 診断メッセージ (出力する順番が来るまで保持)
+	ok     bool
+	stream bool // 大きなファイルのため、出力する順番が来てから逐次読み込む
+}
+
+// render は、ファイルをメモリ上で変換します
+// stream が true の場合、逐次出力の対象となる大きなファイルは読み込まずに印だけを付けます
+func render(file File, relPath string, opt Options, stream bool) *rendered {
+	r := &rendered{log: logging.NewRecorder(logOf(opt).Handler())}
+	if stream {
+		if info, err := file.Stat(); err == nil && info.Size() > streamThreshold && !needsWholeFile(file.Path, opt) {
+			r.stream = true
+			return r
+		}
+	}
+	r.doc, r.ok = convertFile(slog.New(r.log), file, relPath, opt)
+	r.doc.Size = int64(len(r.doc.Content))
+	return r
+}
+
+// renderOrdered は、opt.Jobs 個のワーカーでファイルを並列に変換し、元の順序で emit に渡します
+// 出力を待つ結果がメモリにたまり過ぎないよう、先行して変換する件数を制限します
+// ctx がキャンセルされるか emit がエラーを返した場合は、残りのファイルを変換せずにそのエラーを返します
+func renderOrdered(ctx context.Context, files []File, relPaths []string, opt Options, stream bool, emit func(i int, r *rendered) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := opt.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]chan *rendered, len(files))
+	for i := range results {
+		results[i] = make(chan *rendered, 1)
+	}
+
+	indexes := make(chan int)
+	ahead := make(chan struct{}, jobs*aheadPerJob)
+	go func() {
+		defer close(indexes)
+		for i := range files {
+			select {
+			case ahead <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for n := 0; n < jobs; n++ {
+		go func() {
+			for i := range indexes {
+				results[i] <- render(files[i], relPaths[i], opt, stream)
+			}
+		}()
+	}
+
+	for i := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var r *rendered
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := emit(i, r); err != nil {
+			return err
+		}
+		<-ahead
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/your-org/code2md/internal/logging"
+)
+
+// writeTree は、ベンチマークとテスト用に n 個のソースファイルを含むディレクトリを作成します
+func writeTree(tb testing.TB, n int) []File {
+	tb.Helper()
+	dir := tb.TempDir()
+	files := make([]File, 0, n)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("pkg%02d", i%20), fmt.Sprintf("file%04d.go", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "package pkg%02d\n\n", i%20)
+		for j := 0; j < 50+i%100; j++ {
+			fmt.Fprintf(&b, "func f%d_%d() int { return %d } // コメント\n", i, j, j)
+		}
+		if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			tb.Fatalf("ファイル作成に失敗: %v", err)
+		}
+		files = append(files, File{Path: path})
+	}
+	return files
+}
+
+// 並列数にかかわらず出力と診断メッセージが同じ順序・内容になることを検証
+func TestPrintParallelOrder(t *testing.T) {
+	files := writeTree(t, 60)
+	files = append(files,
+		File{Path: writeTempFile(t, "logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		File{Path: writeTempFile(t, "large.txt", strings.Repeat("large file line\n", 200))},
+		File{Path: filepath.Join(t.TempDir(), "missing.go")},
+	)
+
+	defer func(v int64) { streamThreshold = v }(streamThreshold)
+	streamThreshold = 1024
+
+	run := func(jobs int) (string, string) {
+		var out, log strings.Builder
+		logger, _ := logging.New(&log, logging.FormatText, logging.LevelTrace)
+		if err := Print(&out, files, Options{Jobs: jobs, BinaryPlaceholders: true, Logger: logger}); err != nil {
+			t.Fatalf("Print() エラー: %v", err)
+		}
+		return out.String(), log.String()
+	}
+
+	out, log := run(1)
+	if !strings.Contains(log, "missing.go") || !strings.Contains(log, "msg=total files=61") {
+		t.Errorf("診断メッセージが出力されていません:\n%s", log)
+	}
+	for _, jobs := range []int{2, 8, 0} {
+		gotOut, gotLog := run(jobs)
+		if gotOut != out {
+			t.Errorf("Jobs=%d の出力が Jobs=1 と一致しません", jobs)
+		}
+		if gotLog != log {
+			t.Errorf("Jobs=%d の診断メッセージが Jobs=1 と一致しません:\n%s\nexpected:\n%s", jobs, gotLog, log)
+		}
+	}
+
+	// ファイルの順序が保たれていること
+	prev := -1
+	for _, f := range files[:60] {
+		idx := strings.Index(out, filepath.Base(f.Path))
+		if idx <= prev {
+			t.Fatalf("%s が順序どおりに出力されていません", f.Path)
+		}
+		prev = idx
+	}
+}
+
+func TestConvert(t *testing.T) {
+	files := writeTree(t, 30)
+
+	var got []Document
+	err := Convert(context.Background(), files, Options{Jobs: 4}, func(d Document) error {
+		got = append(got, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Convert() エラー: %v", err)
+	}
+	if len(got) != len(files) {
+		t.Fatalf("Convert() = %d 件, expected %d 件", len(got), len(files))
+	}
+	for i, d := range got {
+		if d.Path != files[i].Path || d.Lang != "go" || d.Stats != countStats(d.Content) {
+			t.Errorf("Convert() [%d] = %+v", i, d)
+		}
+	}
+
+	// fn のエラーで中断する
+	stop := errors.New("stop")
+	n := 0
+	err = Convert(context.Background(), files, Options{Jobs: 4}, func(Document) error {
+		if n++; n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Errorf("Convert() = %v (%d 件), expected %v (3 件)", err, n, stop)
+	}
+
+	// キャンセル済みのコンテキストでは変換しない
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Convert(ctx, files, Options{Jobs: 4}, func(Document) error {
+		t.Error("キャンセル後に fn が呼ばれました")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Convert() = %v, expected %v", err, context.Canceled)
+	}
+}
+
+// 合成したディレクトリ構成に対する Print のベンチマーク
+//
+//	go test ./internal/markdown -run '^$' -bench Print -benchmem
+func BenchmarkPrint(b *testing.B) {
+	files := writeTree(b, 2000)
+
+	for _, jobs := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := Print(io.Discard, files, Options{Jobs: jobs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
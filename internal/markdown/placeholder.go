@@ -0,0 +1,63 @@
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"  // GIF の画像サイズ取得に使用
+	_ "image/jpeg" // JPEG の画像サイズ取得に使用
+	_ "image/png"  // PNG の画像サイズ取得に使用
+	"io"
+	"strings"
+
+	"github.com/your-org/code2md/internal/sniff"
+)
+
+// 画像サイズを取得する形式
+var imageMIMETypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// imageHeadSize は、画像サイズの取得のために保持するファイル先頭のバイト数
+const imageHeadSize = 256 << 10
+
+// binaryPlaceholder は、バイナリファイルの代わりに出力するメタデータを生成します
+// (パス、サイズ、MIMEタイプ、画像サイズ、SHA-256)
+// r はファイルの先頭から読み込めるものを渡します (Seek できないファイルにも対応します)
+func binaryPlaceholder(r io.Reader, relPath string, typ sniff.Type) (string, error) {
+	h := sha256.New()
+	head := &headBuffer{limit: imageHeadSize}
+	size, err := io.Copy(h, io.TeeReader(r, head))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\n", relPath)
+	fmt.Fprintf(&b, "size: %d bytes\n", size)
+	fmt.Fprintf(&b, "type: %s (%s)\n", typ.Name, typ.MIME)
+	if imageMIMETypes[typ.MIME] {
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(head.buf)); err == nil {
+			fmt.Fprintf(&b, "dimensions: %dx%d\n", cfg.Width, cfg.Height)
+		}
+	}
+	fmt.Fprintf(&b, "sha256: %s", hex.EncodeToString(h.Sum(nil)))
+	return b.String(), nil
+}
+
+// headBuffer は、書き込まれた内容の先頭 limit バイトだけを保持します
+type headBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if n := b.limit - len(b.buf); n > 0 {
+		b.buf = append(b.buf, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
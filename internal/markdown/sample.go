@@ -0,0 +1,187 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// sampleFunc は、データファイルを先頭 n 件程度に要約します
+// 要約した場合は内容と説明を、不要な場合は元の内容と空の説明を返します
+type sampleFunc func(content string, n int) (string, string, error)
+
+// 拡張子ごとのデータファイルの要約方法
+var samplers = map[string]sampleFunc{
+	".csv":    sampleCSV(','),
+	".tsv":    sampleCSV('\t'),
+	".json":   sampleJSON,
+	".ndjson": sampleHeadTail,
+	".jsonl":  sampleHeadTail,
+	".log":    sampleHeadTail,
+}
+
+// samplerFor は、ファイルに対応する要約方法を返します
+func samplerFor(path string) (sampleFunc, bool) {
+	f, ok := samplers[strings.ToLower(filepath.Ext(path))]
+	return f, ok
+}
+
+// hasSampler は、ファイルがデータファイルとして要約できるかどうかを返します
+func hasSampler(path string) bool {
+	_, ok := samplerFor(path)
+	return ok
+}
+
+// sampleCSV は、ヘッダー行と先頭 n 行を残し、全体の行数を記載します
+func sampleCSV(comma rune) sampleFunc {
+	return func(content string, n int) (string, string, error) {
+		r := csv.NewReader(strings.NewReader(content))
+		r.Comma = comma
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+
+		var keepOffset int64
+		records := 0
+		for {
+			if _, err := r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return content, "", err
+			}
+			records++
+			// ヘッダー行 + n 行までの位置を記録
+			if records == n+1 {
+				keepOffset = r.InputOffset()
+			}
+		}
+
+		rows := records - 1 // ヘッダーを除く
+		if rows <= n {
+			return content, "", nil
+		}
+		out := strings.TrimRight(content[:keepOffset], "\r\n")
+		note := fmt.Sprintf("... %d more rows (%d rows total)", rows-n, rows)
+		return out + "\n" + note + "\n", note, nil
+	}
+}
+
+// sampleHeadTail は、NDJSON やログファイルの先頭 n 行と末尾 n 行を残します
+func sampleHeadTail(content string, n int) (string, string, error) {
+	out, omitted := truncateLines(content, Truncation{Head: n, Tail: n})
+	if omitted == 0 {
+		return content, "", nil
+	}
+	return out, fmt.Sprintf("%d lines omitted", omitted), nil
+}
+
+// sampleJSON は、n 件を超える配列を切り詰め、残りの件数を文字列要素として追記します
+// オブジェクトのキーの順序と全体の構造は維持されます
+func sampleJSON(content string, n int) (string, string, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	truncated := 0
+	if err := sampleJSONValue(dec, &buf, n, &truncated); err != nil {
+		return content, "", err
+	}
+	// 複数の値が連続する場合 (NDJSON など) は要約しない
+	if _, err := dec.Token(); err != io.EOF {
+		return content, "", nil
+	}
+	if truncated == 0 {
+		return content, "", nil
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return content, "", err
+	}
+	out.WriteByte('\n')
+	return out.String(), fmt.Sprintf("%d arrays truncated", truncated), nil
+}
+
+// sampleJSONValue は、デコーダーから値を1つ読み込み、配列を切り詰めながら buf に書き出します
+func sampleJSONValue(dec *json.Decoder, buf *bytes.Buffer, n int, truncated *int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return writeJSON(buf, tok)
+	}
+
+	switch delim {
+	case '{':
+		buf.WriteByte('{')
+		for i := 0; dec.More(); i++ {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := sampleJSONValue(dec, buf, n, truncated); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case '[':
+		buf.WriteByte('[')
+		total := 0
+		for ; dec.More(); total++ {
+			if total >= n {
+				// 残りの要素は読み飛ばす
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return err
+				}
+				continue
+			}
+			if total > 0 {
+				buf.WriteByte(',')
+			}
+			if err := sampleJSONValue(dec, buf, n, truncated); err != nil {
+				return err
+			}
+		}
+		if total > n {
+			*truncated++
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, fmt.Sprintf("... %d more items (%d total)", total-n, total)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+
+	// 閉じ括弧を読み込む
+	_, err = dec.Token()
+	return err
+}
+
+// writeJSON は、値を JSON として buf に書き出します
+// 元のファイルの内容を保つため、<, >, & はエスケープしません
+func writeJSON(buf *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode が追加する改行を取り除く
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
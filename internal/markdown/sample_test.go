@@ -0,0 +1,80 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSampleCSV(t *testing.T) {
+	content := "id,name,note\n1,a,\"multi\nline\"\n2,b,x\n3,c,y\n4,d,z\n"
+
+	got, note, err := sampleCSV(',')(content, 2)
+	if err != nil {
+		t.Fatalf("sampleCSV() エラー: %v", err)
+	}
+	expected := "id,name,note\n1,a,\"multi\nline\"\n2,b,x\n... 2 more rows (4 rows total)\n"
+	if got != expected {
+		t.Errorf("sampleCSV() = %q, expected %q", got, expected)
+	}
+	if note == "" {
+		t.Error("要約した場合は説明を返すべきです")
+	}
+
+	// 行数が範囲内の場合はそのまま
+	if got, note, _ := sampleCSV(',')(content, 10); got != content || note != "" {
+		t.Errorf("sampleCSV() = (%q, %q), expected unchanged", got, note)
+	}
+}
+
+func TestSampleJSON(t *testing.T) {
+	content := `{"name": "fixture", "items": [1, 2, 3, 4, 5], "nested": {"tags": ["a", "b"]}, "html": "<b>&amp;</b>", "z": 1.50}`
+
+	got, _, err := sampleJSON(content, 2)
+	if err != nil {
+		t.Fatalf("sampleJSON() エラー: %v", err)
+	}
+	expected := `{
+  "name": "fixture",
+  "items": [
+    1,
+    2,
+    "... 3 more items (5 total)"
+  ],
+  "nested": {
+    "tags": [
+      "a",
+      "b"
+    ]
+  },
+  "html": "<b>&amp;</b>",
+  "z": 1.50
+}
+`
+	if got != expected {
+		t.Errorf("sampleJSON() = %s, expected %s", got, expected)
+	}
+
+	// 切り詰めが不要な場合は元の書式を維持
+	if got, note, _ := sampleJSON(content, 5); got != content || note != "" {
+		t.Errorf("sampleJSON() = (%q, %q), expected unchanged", got, note)
+	}
+
+	// トップレベルの配列
+	got, _, _ = sampleJSON(`[{"a": 1}, {"a": 2}, {"a": 3}]`, 1)
+	if !strings.Contains(got, `"... 2 more items (3 total)"`) {
+		t.Errorf("sampleJSON() = %s", got)
+	}
+
+	// NDJSON のように複数の値が続く場合は変更しない
+	ndjson := "[1,2,3]\n[4,5,6]\n"
+	if got, _, _ := sampleJSON(ndjson, 1); got != ndjson {
+		t.Errorf("sampleJSON() = %q, expected unchanged", got)
+	}
+}
+
+func TestSampleHeadTail(t *testing.T) {
+	got, _, _ := sampleHeadTail("1\n2\n3\n4\n5\n6\n", 2)
+	if got != "1\n2\n... 2 lines omitted ...\n5\n6\n" {
+		t.Errorf("sampleHeadTail() = %q", got)
+	}
+}
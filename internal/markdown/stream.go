@@ -0,0 +1,330 @@
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// streamBufferSize は、大きなファイルを書き出す際の読み書きのバッファサイズ
+const streamBufferSize = 64 << 10
+
+// streamThreshold を超えるファイルは、全体を読み込まずに先頭から順に書き出します
+// (テストで小さくできるよう変数にしています)
+var streamThreshold int64 = 1 << 20
+
+// Stats は、出力した内容の行数・単語数・文字数
+type Stats struct {
+	Lines int
+	Words int
+	Chars int
+}
+
+// statsWriter は、書き込んだ内容の統計を数えながら w に書き出します
+// 書き込みの境界で途切れたUTF-8の文字は次の書き込みとつなげて数えます
+type statsWriter struct {
+	w      io.Writer
+	stats  Stats
+	inWord bool
+	carry  []byte // 前回の書き込みの末尾で途切れた文字
+	size   int64  // 書き込んだバイト数
+	err    error  // 最初の書き込みエラー (読み込みのエラーと区別するために保持)
+}
+
+func (s *statsWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.size += int64(n)
+	s.count(p[:n])
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// count は、書き込んだバイト列を文字単位で数えます
+func (s *statsWriter) count(p []byte) {
+	if len(s.carry) > 0 {
+		for len(p) > 0 && !utf8.FullRune(s.carry) {
+			s.carry, p = append(s.carry, p[0]), p[1:]
+		}
+		if !utf8.FullRune(s.carry) {
+			return
+		}
+		r, size := utf8.DecodeRune(s.carry)
+		s.rune(r)
+		// 不正なバイト列の場合、残りのバイトは改めて数える
+		p = append(append([]byte{}, s.carry[size:]...), p...)
+		s.carry = s.carry[:0]
+	}
+
+	for len(p) > 0 {
+		if p[0] < utf8.RuneSelf {
+			s.rune(rune(p[0]))
+			p = p[1:]
+			continue
+		}
+		if !utf8.FullRune(p) {
+			s.carry = append(s.carry, p...)
+			return
+		}
+		r, size := utf8.DecodeRune(p)
+		s.rune(r)
+		p = p[size:]
+	}
+}
+
+// rune は、1文字分の統計を加算します (strings.Fields と同じ基準で単語を数えます)
+func (s *statsWriter) rune(r rune) {
+	s.stats.Chars++
+	if r == '\n' {
+		s.stats.Lines++
+	}
+	if unicode.IsSpace(r) {
+		s.inWord = false
+	} else if !s.inWord {
+		s.inWord = true
+		s.stats.Words++
+	}
+}
+
+// Stats は、これまでに書き込んだ内容の統計を返します
+// 途切れたままの文字は不正なバイトとして1バイトずつ数えます
+func (s *statsWriter) Stats() Stats {
+	for range s.carry {
+		s.rune(utf8.RuneError)
+	}
+	s.carry = nil
+	return s.stats
+}
+
+// textStream は、テキストを1バイトずつ正規化・切り詰めしながら書き出します
+// 切り詰めで末尾に残す行を除き、内容全体をメモリに保持しません
+//
+// normalize、truncateLines、withFinalNewline を順に適用した場合と同じ結果になります
+type textStream struct {
+	w     *bufio.Writer
+	norm  Normalize
+	trunc Truncation
+
+	// 改行コードと行末の空白
+	pendingCR bool
+	spaces    []byte
+
+	// 切り詰め
+	line    int      // 先頭から数えた現在の行
+	cur     []byte   // 末尾に残す候補の現在の行 (Tail が 0 の場合は保持しない)
+	curLen  int      // 現在の行のバイト数
+	tail    [][]byte // 末尾に残す候補の行
+	omitted int
+
+	// 末尾の改行
+	newlines []byte // 保留中の改行 (後に内容が続く場合のみ書き出す)
+	started  bool
+}
+
+// streamText は、r から読み込んだテキストを正規化・切り詰めしながら w に書き出し、
+// 省略した行数を返します
+func streamText(w io.Writer, r io.Reader, n Normalize, t Truncation) (int, error) {
+	br := bufio.NewReaderSize(r, streamBufferSize)
+	if n.StripBOM {
+		if b, _ := br.Peek(3); string(b) == "\ufeff" {
+			br.Discard(3)
+		}
+	}
+
+	s := &textStream{w: bufio.NewWriterSize(w, streamBufferSize), norm: n, trunc: t}
+	buf := make([]byte, streamBufferSize)
+	for {
+		k, err := br.Read(buf)
+		for _, b := range buf[:k] {
+			s.crlf(b)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.w.Flush()
+			return s.omitted, err
+		}
+	}
+
+	s.close()
+	return s.omitted, s.w.Flush()
+}
+
+// crlf は、CRLF / CR を LF に変換します
+func (s *textStream) crlf(b byte) {
+	if !s.norm.CRLFToLF {
+		s.trimSpace(b)
+		return
+	}
+	if s.pendingCR {
+		s.pendingCR = false
+		s.trimSpace('\n')
+		if b == '\n' {
+			return
+		}
+	}
+	if b == '\r' {
+		s.pendingCR = true
+		return
+	}
+	s.trimSpace(b)
+}
+
+// trimSpace は、行末の空白とタブを取り除きます
+func (s *textStream) trimSpace(b byte) {
+	if !s.norm.TrimTrailingSpace {
+		s.truncate(b)
+		return
+	}
+	switch b {
+	case ' ', '\t':
+		s.spaces = append(s.spaces, b)
+		return
+	case '\n':
+		s.spaces = s.spaces[:0]
+	default:
+		for _, sp := range s.spaces {
+			s.truncate(sp)
+		}
+		s.spaces = s.spaces[:0]
+	}
+	s.truncate(b)
+}
+
+// truncate は、先頭 Head 行をそのまま書き出し、それ以降は末尾 Tail 行だけを保持します
+func (s *textStream) truncate(b byte) {
+	if !s.trunc.enabled() || s.line < s.trunc.Head {
+		s.finalNewline(b)
+		if b == '\n' {
+			s.line++
+		}
+		return
+	}
+
+	s.curLen++
+	if s.trunc.Tail > 0 {
+		s.cur = append(s.cur, b)
+	}
+	if b == '\n' {
+		s.pushLine()
+	}
+}
+
+// pushLine は、現在の行を末尾の候補に加え、あふれた行を省略した行として数えます
+func (s *textStream) pushLine() {
+	s.line++
+	s.tail = append(s.tail, s.cur)
+	if len(s.tail) > s.trunc.Tail {
+		s.tail = s.tail[1:]
+		s.omitted++
+	}
+	s.cur, s.curLen = nil, 0
+}
+
+// finalNewline は、末尾の改行をちょうど1つにそろえます
+func (s *textStream) finalNewline(b byte) {
+	if b == '\r' || b == '\n' {
+		s.newlines = append(s.newlines, b)
+		return
+	}
+	s.w.Write(s.newlines)
+	s.newlines = s.newlines[:0]
+	s.w.WriteByte(b)
+	s.started = true
+}
+
+// close は、保留中の内容を書き出します
+func (s *textStream) close() {
+	if s.pendingCR {
+		s.pendingCR = false
+		s.trimSpace('\n')
+	}
+	// 最終行の行末の空白は取り除く
+	s.spaces = s.spaces[:0]
+
+	if s.curLen > 0 {
+		s.pushLine()
+	}
+	if s.omitted > 0 {
+		for _, b := range []byte(fmt.Sprintf("... %d lines omitted ...\n", s.omitted)) {
+			s.finalNewline(b)
+		}
+	}
+	for _, line := range s.tail {
+		for _, b := range line {
+			s.finalNewline(b)
+		}
+	}
+
+	if s.started {
+		s.w.WriteString(lineEnding(string(s.newlines)))
+	}
+}
+
+// fenceScanner は、書き込まれた内容の行頭にあるバッククォートの最長の並びを数えます
+// 内容全体を保持せずに fenceFor と同じ区切りを求めるために使用します
+type fenceScanner struct {
+	inLine  bool // 行頭の空白とバッククォートより後ろを読んでいる
+	run     int  // 現在の行の行頭のバッククォートの数
+	longest int
+}
+
+func (s *fenceScanner) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch {
+		case b == '\n' || b == '\r':
+			s.inLine, s.run = false, 0
+		case s.inLine:
+		case b == '`':
+			s.run++
+			s.longest = max(s.longest, s.run)
+		case b == ' ' && s.run == 0:
+		default:
+			s.inLine = true
+		}
+	}
+	return len(p), nil
+}
+
+// fence は、これまでに書き込まれた内容を囲むコードフェンスを返します
+func (s *fenceScanner) fence() string {
+	return strings.Repeat("`", max(3, s.longest+1))
+}
+
+// utf8Replacer は、UTF-8として不正なバイトを U+FFFD に置き換え、その数を数えます
+// 先頭部分だけでUTF-8と判定したファイルの後半に、別の文字コードが含まれる場合に使用します
+type utf8Replacer struct {
+	invalid int
+}
+
+func (u *utf8Replacer) Reset() {}
+
+func (u *utf8Replacer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		r, size := utf8.DecodeRune(src[nSrc:])
+		out := src[nSrc : nSrc+size]
+		if r == utf8.RuneError && size == 1 {
+			// 途切れた文字は次の読み込みとつなげて判定する
+			if !atEOF && !utf8.FullRune(src[nSrc:]) {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			out = []byte(string(utf8.RuneError))
+		}
+		if nDst+len(out) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		if len(out) != size {
+			u.invalid++
+		}
+		nDst += copy(dst[nDst:], out)
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,266 @@
+package markdown
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/your-org/code2md/internal/logging"
+	"golang.org/x/text/transform"
+)
+
+// streamText が normalize・truncateLines・withFinalNewline を順に適用した結果と一致することを検証
+func TestStreamTextMatchesInMemory(t *testing.T) {
+	inputs := []string{
+		"",
+		"\n\n",
+		"a",
+		"a\n",
+		"\n\na\n\n\n",
+		"\ufeffpackage main\r\n\r\nfunc main() {}  \r\n",
+		"mac\rline\r\rend\r",
+		"tabs\t \nspaces   \n  \n\t\nlast  ",
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+		"1\r\n2\r\n3\r\n4\r\n5\r\n\r\n\r\n",
+		"日本語の行\n　全角空白　\nend\n",
+	}
+	normalizations := []Normalize{
+		{},
+		{StripBOM: true, CRLFToLF: true},
+		{StripBOM: true, CRLFToLF: true, TrimTrailingSpace: true},
+		{TrimTrailingSpace: true},
+	}
+	truncations := []Truncation{
+		{},
+		{Head: 2, Tail: 3},
+		{Head: 3},
+		{Tail: 1},
+		{Head: 1, Tail: 1},
+	}
+
+	for _, input := range inputs {
+		for _, n := range normalizations {
+			for _, tr := range truncations {
+				expected, expectedOmitted := truncateLines(normalize(input, n), tr)
+				expected = withFinalNewline(expected)
+
+				// 1バイトずつ読み込んでも結果が変わらないこと
+				var buf strings.Builder
+				omitted, err := streamText(&buf, iotest.OneByteReader(strings.NewReader(input)), n, tr)
+				if err != nil {
+					t.Fatalf("streamText() エラー: %v", err)
+				}
+				if buf.String() != expected || omitted != expectedOmitted {
+					t.Errorf("streamText(%q, %+v, %+v) = (%q, %d), expected (%q, %d)",
+						input, n, tr, buf.String(), omitted, expected, expectedOmitted)
+				}
+			}
+		}
+	}
+}
+
+// statsWriter が分割して書き込まれた内容を文字単位で正しく数えることを検証
+func TestStatsWriter(t *testing.T) {
+	inputs := []string{
+		"",
+		"hello world\n",
+		"日本語　の　単語\n二行目\n",
+		"broken \xe3\x81 rune\n",
+		"tail \xe3\x81",
+	}
+
+	for _, input := range inputs {
+		for _, chunk := range []int{1, 2, 3, len(input) + 1} {
+			sw := &statsWriter{w: &strings.Builder{}}
+			for i := 0; i < len(input); i += chunk {
+				sw.Write([]byte(input[i:min(i+chunk, len(input))]))
+			}
+			expected := Stats{
+				Lines: strings.Count(input, "\n"),
+				Words: len(strings.Fields(input)),
+				Chars: utf8.RuneCountInString(input),
+			}
+			if got := sw.Stats(); got != expected {
+				t.Errorf("statsWriter(%q, chunk=%d) = %+v, expected %+v", input, chunk, got, expected)
+			}
+		}
+	}
+}
+
+// しきい値を超えるファイルを逐次出力しても、全体を読み込んだ場合と同じ出力になることを検証
+func TestPrintStreamsLargeFiles(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("\ufeff// こんにちは\r\n")
+	for i := 0; i < 200; i++ {
+		content.WriteString("func f() {}   \r\n")
+	}
+	path := writeTempFile(t, "large.go", content.String())
+	// "こんにちは" を Shift_JIS で記述したファイル
+	sjis := writeTempFile(t, "legacy.c", strings.Repeat("// \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n", 100))
+	// コードブロックを含むファイルは、より長い区切りで囲む
+	doc := writeTempFile(t, "README.md", strings.Repeat("```go\nfunc f() {}\n```\n", 50))
+
+	opt := Options{
+		Normalize: Normalize{StripBOM: true, CRLFToLF: true, TrimTrailingSpace: true},
+		Truncate:  Truncation{Head: 5, Tail: 5},
+	}
+	files := []File{{Path: path}, {Path: sjis}, {Path: doc}}
+
+	var expected strings.Builder
+	if err := Print(&expected, files, opt); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+
+	defer func(v int64) { streamThreshold = v }(streamThreshold)
+	streamThreshold = 16
+
+	var got strings.Builder
+	if err := Print(&got, files, opt); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if got.String() != expected.String() {
+		t.Errorf("逐次出力の結果が一致しません:\n%s\nexpected:\n%s", got.String(), expected.String())
+	}
+	if !strings.Contains(got.String(), "legacy.c encoding=Shift_JIS\n// こんにちは\n") {
+		t.Errorf("Shift_JIS のファイルがUTF-8に変換されていません:\n%s", got.String())
+	}
+	if !strings.Contains(got.String(), "````markdown:") || !strings.HasSuffix(got.String(), "```\n````\n\n") {
+		t.Errorf("バッククォートを含むファイルの区切りが長くなっていません:\n%s", got.String())
+	}
+}
+
+// 先頭部分より後ろにあるバッククォートの並びも区切りの長さに反映されることを検証
+func TestPrintStreamsFenceFromWholeFile(t *testing.T) {
+	// 判定に使用する先頭部分 (64KiB) を超えた位置にコードブロックがあるファイル
+	content := strings.Repeat("plain text line\n", 5000) + "  ````go\nfunc f() {}\n````\nend\n"
+	path := writeTempFile(t, "notes.txt", content)
+
+	defer func(v int64) { streamThreshold = v }(streamThreshold)
+	streamThreshold = 16
+
+	tests := []struct {
+		name  string
+		trunc Truncation
+	}{
+		{"切り詰めなし", Truncation{}},
+		// 末尾に残す行にだけコードブロックが含まれる
+		{"末尾の行を残す", Truncation{Head: 3, Tail: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Print(&out, []File{{Path: path}}, Options{Truncate: tt.trunc}); err != nil {
+				t.Fatalf("Print() エラー: %v", err)
+			}
+			got := out.String()
+			if !strings.HasPrefix(got, "`````") || !strings.HasSuffix(got, "end\n`````\n\n") {
+				t.Errorf("区切りがバッククォートの並びより長くなっていません:\n%s", got[max(0, len(got)-200):])
+			}
+		})
+	}
+}
+
+// 先頭部分でUTF-8と判定したファイルの後半にある不正なバイトを置き換えて警告することを検証
+func TestPrintStreamsInvalidUTF8(t *testing.T) {
+	// ASCII の後に "こんにちは" を Shift_JIS で記述したファイル
+	content := strings.Repeat("ascii line\n", 7000) + "// \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n"
+	path := writeTempFile(t, "mixed.c", content)
+
+	defer func(v int64) { streamThreshold = v }(streamThreshold)
+	streamThreshold = 16
+
+	var out, log strings.Builder
+	logger, _ := logging.New(&log, logging.FormatText, logging.LevelTrace)
+	if err := Print(&out, []File{{Path: path}}, Options{Logger: logger}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if !utf8.ValidString(out.String()) {
+		t.Errorf("出力に不正なUTF-8が含まれています")
+	}
+	if !strings.Contains(out.String(), "// \ufffd\ufffd\ufffd") {
+		t.Errorf("不正なバイトが U+FFFD に置き換えられていません:\n%s", out.String()[out.Len()-100:])
+	}
+	if !strings.Contains(log.String(), `level=WARN msg="replaced invalid UTF-8 bytes"`) {
+		t.Errorf("不正なバイトの警告が出力されていません:\n%s", log.String())
+	}
+}
+
+// utf8Replacer が途切れた文字を次の読み込みとつなげて判定することを検証
+func TestUTF8Replacer(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		invalid  int
+	}{
+		{"hello", "hello", 0},
+		{"日本語\n", "日本語\n", 0},
+		{"a\xffb", "a\ufffdb", 1},
+		{"\x82\xb1", "\ufffd\ufffd", 2},
+		{"end \xe3\x81", "end \ufffd\ufffd", 2},
+		{"\ufffd", "\ufffd", 0},
+	}
+
+	for _, tt := range tests {
+		r := &utf8Replacer{}
+		var out strings.Builder
+		if _, err := io.Copy(&out, transform.NewReader(iotest.OneByteReader(strings.NewReader(tt.input)), r)); err != nil {
+			t.Fatalf("読み込みエラー: %v", err)
+		}
+		if out.String() != tt.expected || r.invalid != tt.invalid {
+			t.Errorf("utf8Replacer(%q) = (%q, %d), expected (%q, %d)", tt.input, out.String(), r.invalid, tt.expected, tt.invalid)
+		}
+	}
+}
+
+// Measure が大きなファイルを保持せずに、Convert と同じサイズと統計を返すことを検証
+func TestMeasureMatchesConvert(t *testing.T) {
+	files := []File{
+		{Path: writeTempFile(t, "large.go", strings.Repeat("func f() {}   \r\n", 300))},
+		{Path: writeTempFile(t, "small.txt", "hello\n")},
+		{Path: writeTempFile(t, "go.sum", strings.Repeat("example.com/m v1.0.0 h1:abc=\n", 100))},
+		{Path: writeTempFile(t, "logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+	}
+	opt := Options{
+		Normalize: Normalize{CRLFToLF: true, TrimTrailingSpace: true},
+		Truncate:  Truncation{Head: 5, Tail: 5},
+		Lockfiles: LockfileSummary,
+	}
+
+	collect := func(convert func(context.Context, []File, Options, func(Document) error) error) []Document {
+		var docs []Document
+		if err := convert(context.Background(), files, opt, func(d Document) error {
+			docs = append(docs, d)
+			return nil
+		}); err != nil {
+			t.Fatalf("変換エラー: %v", err)
+		}
+		return docs
+	}
+	expected := collect(Convert)
+
+	defer func(v int64) { streamThreshold = v }(streamThreshold)
+	streamThreshold = 64
+	got := collect(Measure)
+
+	if len(got) != len(expected) {
+		t.Fatalf("Measure() の件数 = %d, expected %d", len(got), len(expected))
+	}
+	for i := range got {
+		if got[i].Size != int64(len(expected[i].Content)) || got[i].Stats != expected[i].Stats {
+			t.Errorf("%s: Measure() = (%d, %+v), expected (%d, %+v)",
+				got[i].RelPath, got[i].Size, got[i].Stats, len(expected[i].Content), expected[i].Stats)
+		}
+	}
+	// しきい値を超え、全体を読み込む必要のないファイルは内容を保持しない
+	if got[0].Content != "" {
+		t.Errorf("大きなファイルの内容が保持されています")
+	}
+	// ロックファイルの要約は全体を読み込んで変換する
+	if got[2].Content != expected[2].Content {
+		t.Errorf("ロックファイルの要約が Convert と一致しません:\n%s", got[2].Content)
+	}
+}
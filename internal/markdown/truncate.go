@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Truncation は、長いファイルの先頭 Head 行と末尾 Tail 行だけを残す設定
+// 両方が 0 の場合は切り詰めを行いません
+type Truncation struct {
+	Head int
+	Tail int
+}
+
+// TruncateRule は、globパターンに一致するファイルに適用する切り詰め設定
+// パターンに '/' を含まない場合はファイル名、含む場合は相対パスと照合します
+type TruncateRule struct {
+	Pattern string
+	Truncation
+}
+
+// enabled は、切り詰めが有効かどうかを返します
+func (t Truncation) enabled() bool {
+	return t.Head > 0 || t.Tail > 0
+}
+
+// ParseTruncation は、"HEAD:TAIL" 形式の文字列を Truncation に変換します
+func ParseTruncation(s string) (Truncation, error) {
+	head, tail, ok := strings.Cut(s, ":")
+	if !ok {
+		return Truncation{}, fmt.Errorf("invalid truncation %q (expected HEAD:TAIL)", s)
+	}
+	h, err := strconv.Atoi(strings.TrimSpace(head))
+	if err != nil || h < 0 {
+		return Truncation{}, fmt.Errorf("invalid head line count in %q", s)
+	}
+	t, err := strconv.Atoi(strings.TrimSpace(tail))
+	if err != nil || t < 0 {
+		return Truncation{}, fmt.Errorf("invalid tail line count in %q", s)
+	}
+	return Truncation{Head: h, Tail: t}, nil
+}
+
+// ParseTruncateRule は、"PATTERN=HEAD:TAIL" 形式の文字列を TruncateRule に変換します
+func ParseTruncateRule(s string) (TruncateRule, error) {
+	pattern, spec, ok := strings.Cut(s, "=")
+	if !ok || pattern == "" {
+		return TruncateRule{}, fmt.Errorf("invalid truncate rule %q (expected PATTERN=HEAD:TAIL)", s)
+	}
+	t, err := ParseTruncation(spec)
+	if err != nil {
+		return TruncateRule{}, err
+	}
+	return TruncateRule{Pattern: pattern, Truncation: t}, nil
+}
+
+// truncationFor は、ファイルに適用する切り詰め設定を返します
+// 最初に一致したルールを優先し、どれにも一致しなければ全体設定を使用します
+func truncationFor(relPath string, opt Options) Truncation {
+	slashPath := filepath.ToSlash(relPath)
+	name := filepath.Base(relPath)
+	for _, r := range opt.TruncateRules {
+		target := name
+		if strings.Contains(r.Pattern, "/") {
+			target = slashPath
+		}
+		if ok, _ := doublestar.Match(r.Pattern, target); ok {
+			return r.Truncation
+		}
+	}
+	return opt.Truncate
+}
+
+// truncateLines は、内容が Head+Tail 行を超える場合に中間を省略し、
+// 省略した行数を示すマーカーに置き換えます
+func truncateLines(content string, t Truncation) (string, int) {
+	if !t.enabled() {
+		return content, 0
+	}
+
+	body, trailingNewline := strings.CutSuffix(content, "\n")
+	lines := strings.Split(body, "\n")
+	if len(lines) <= t.Head+t.Tail {
+		return content, 0
+	}
+
+	omitted := len(lines) - t.Head - t.Tail
+	kept := make([]string, 0, t.Head+t.Tail+1)
+	kept = append(kept, lines[:t.Head]...)
+	kept = append(kept, fmt.Sprintf("... %d lines omitted ...", omitted))
+	kept = append(kept, lines[len(lines)-t.Tail:]...)
+
+	out := strings.Join(kept, "\n")
+	if trailingNewline {
+		out += "\n"
+	}
+	return out, omitted
+}
@@ -0,0 +1,93 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateLines(t *testing.T) {
+	content := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+
+	tests := []struct {
+		name            string
+		truncation      Truncation
+		expected        string
+		expectedOmitted int
+	}{
+		{"切り詰めなし", Truncation{}, content, 0},
+		{"先頭と末尾", Truncation{Head: 2, Tail: 3}, "1\n2\n... 5 lines omitted ...\n8\n9\n10\n", 5},
+		{"先頭のみ", Truncation{Head: 3}, "1\n2\n3\n... 7 lines omitted ...\n", 7},
+		{"末尾のみ", Truncation{Tail: 1}, "... 9 lines omitted ...\n10\n", 9},
+		{"行数が範囲内", Truncation{Head: 5, Tail: 5}, content, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, omitted := truncateLines(content, tt.truncation)
+			if got != tt.expected || omitted != tt.expectedOmitted {
+				t.Errorf("truncateLines() = (%q, %d), expected (%q, %d)", got, omitted, tt.expected, tt.expectedOmitted)
+			}
+		})
+	}
+
+	// 末尾に改行がない場合も改行を追加しない
+	got, _ := truncateLines("a\nb\nc\nd", Truncation{Head: 1, Tail: 1})
+	if got != "a\n... 2 lines omitted ...\nd" {
+		t.Errorf("truncateLines() = %q", got)
+	}
+}
+
+func TestParseTruncateRule(t *testing.T) {
+	rule, err := ParseTruncateRule("*.log=50:20")
+	if err != nil {
+		t.Fatalf("ParseTruncateRule() エラー: %v", err)
+	}
+	if rule.Pattern != "*.log" || rule.Head != 50 || rule.Tail != 20 {
+		t.Errorf("ParseTruncateRule() = %+v", rule)
+	}
+
+	for _, invalid := range []string{"*.log", "=1:2", "*.log=1", "*.log=a:2", "*.log=1:-2"} {
+		if _, err := ParseTruncateRule(invalid); err == nil {
+			t.Errorf("ParseTruncateRule(%q) はエラーを返すべきです", invalid)
+		}
+	}
+}
+
+func TestTruncationFor(t *testing.T) {
+	opt := Options{
+		Truncate: Truncation{Head: 100, Tail: 10},
+		TruncateRules: []TruncateRule{
+			{Pattern: "*.log", Truncation: Truncation{Head: 5, Tail: 5}},
+			{Pattern: "testdata/**", Truncation: Truncation{Head: 1}},
+			{Pattern: "*.go"},
+		},
+	}
+
+	tests := []struct {
+		path     string
+		expected Truncation
+	}{
+		{"logs/app.log", Truncation{Head: 5, Tail: 5}},
+		{"testdata/big.txt", Truncation{Head: 1}},
+		{"main.go", Truncation{}},
+		{"README.md", Truncation{Head: 100, Tail: 10}},
+	}
+
+	for _, tt := range tests {
+		if got := truncationFor(tt.path, opt); got != tt.expected {
+			t.Errorf("truncationFor(%q) = %+v, expected %+v", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestPrintTruncates(t *testing.T) {
+	path := writeTempFile(t, "big.txt", strings.Repeat("line\n", 100))
+
+	var buf strings.Builder
+	if err := Print(&buf, []File{{Path: path}}, Options{Truncate: Truncation{Head: 2, Tail: 2}}); err != nil {
+		t.Fatalf("Print() エラー: %v", err)
+	}
+	if !strings.Contains(buf.String(), "line\nline\n... 96 lines omitted ...\nline\nline\n") {
+		t.Errorf("Print() の出力が切り詰められていません:\n%s", buf.String())
+	}
+}
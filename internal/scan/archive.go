@@ -0,0 +1,368 @@
+package scan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveKind は、ファイル名から入力として展開できるアーカイブの種類 ("zip", "tar", "tar.gz") を返します
+// アーカイブでない場合は空文字列を返します
+func archiveKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// gatherArchive は、アーカイブの内容をディレクトリと同じ規則で収集します
+// 収集したファイルの Path は "<アーカイブの絶対パス>/<アーカイブ内のパス>" になります
+func (g *gatherer) gatherArchive(ctx context.Context, arg, absPath string) error {
+	// アーカイブ自体のファイル名をチェック
+	base := filepath.Base(absPath)
+	if !g.opt.IncludeDotfiles && base[0] == '.' {
+		return nil
+	}
+	if isIgnored(base, g.ignore) {
+		g.log.Info("ignored", "path", absPath, "reason", "file pattern")
+		return nil
+	}
+
+	display := func(name string) string {
+		return filepath.Join(absPath, filepath.FromSlash(name))
+	}
+	limits := &archiveLimits{
+		maxFile:  g.opt.MaxFileSize,
+		maxTotal: archiveBudget,
+		skip: func(name, reason string) {
+			g.log.Info("ignored", "path", display(name), "reason", reason)
+		},
+	}
+	g.log.Info("reading archive", "path", arg)
+	fsys, err := openArchive(absPath, limits)
+	if err != nil {
+		g.log.Error("could not read archive", "path", arg, "err", err)
+		return nil
+	}
+
+	// アーカイブのルートをリポジトリのルートとみなす
+	attrs := newGitAttributes(fsys)
+	attrs.rootIsRepo = true
+	return g.gather(ctx, fsys, attrs, arg, ".", display)
+}
+
+// archiveBudget は、1つのアーカイブからメモリに展開する内容の合計サイズの上限
+// 圧縮率の極端に高いアーカイブでメモリを使い果たさないよう、超えた分のファイルは読み込みません
+var archiveBudget int64 = 512 << 20
+
+// archiveLimits は、アーカイブのエントリを読み込む前に確認するサイズの上限
+type archiveLimits struct {
+	maxFile  int64                     // ファイル単位の上限 (0は制限なし)
+	maxTotal int64                     // 展開する内容の合計の上限
+	total    int64                     // これまでに展開した内容の合計
+	skip     func(name, reason string) // 読み込まなかったエントリの通知 (nil の場合は通知しない)
+}
+
+// skipped は、読み込まなかったエントリを通知します
+func (l *archiveLimits) skipped(name, reason string) {
+	if l.skip != nil {
+		l.skip(name, reason)
+	}
+}
+
+// admit は、ヘッダーに記録されたサイズから、エントリを読み込むかを判定します
+func (l *archiveLimits) admit(name string, size int64) bool {
+	switch {
+	case l.maxFile > 0 && size > l.maxFile:
+		l.skipped(name, fmt.Sprintf("size: %s exceeds --max-file-size %s", FormatSize(size), FormatSize(l.maxFile)))
+		return false
+	case l.total+size > l.maxTotal:
+		l.skipped(name, fmt.Sprintf("size: %s would exceed the archive limit %s", FormatSize(size), FormatSize(l.maxTotal)))
+		return false
+	}
+	return true
+}
+
+// read は、エントリの内容をヘッダーに記録されたサイズまで読み込みます
+// 実際の内容がそれより長い場合は、壊れたアーカイブとしてエラーを返します
+func (l *archiveLimits) read(name string, r io.Reader, size int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > size {
+		return nil, fmt.Errorf("%s: content is larger than its header says", name)
+	}
+	l.total += int64(len(data))
+	return data, nil
+}
+
+// openArchive は、アーカイブの内容をメモリに読み込み、fs.FS として返します
+// limits を超えるファイルは読み込みません
+func openArchive(filePath string, limits *archiveLimits) (fs.FS, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch archiveKind(filePath) {
+	case "zip":
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return readZip(f, info.Size(), limits)
+	case "tar":
+		return readTar(f, limits)
+	case "tar.gz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readTar(gz, limits)
+	}
+	return nil, fmt.Errorf("unsupported archive: %s", filePath)
+}
+
+// readZip は、zip の通常のファイルを読み込んで memFS を作成します
+func readZip(r io.ReaderAt, size int64, limits *archiveLimits) (fs.FS, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMemFS()
+	for _, f := range zr.File {
+		name, ok := archivePath(f.Name)
+		if !ok {
+			continue
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if _, err := m.dir(name, f.Modified); err != nil {
+				limits.skipped(name, err.Error())
+			}
+		case mode.IsRegular():
+			// 非常に大きな値は int64 では負になるため、uint64 のまま確認する
+			if f.UncompressedSize64 > math.MaxInt64 || !limits.admit(name, int64(f.UncompressedSize64)) {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := limits.read(name, r, int64(f.UncompressedSize64))
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+			if err := m.file(name, content, mode, f.Modified); err != nil {
+				limits.skipped(name, err.Error())
+			}
+		}
+	}
+	return m, nil
+}
+
+// readTar は、tar の通常のファイルを読み込んで memFS を作成します
+// シンボリックリンクやデバイスファイルなどは含めません
+func readTar(r io.Reader, limits *archiveLimits) (fs.FS, error) {
+	m := newMemFS()
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := archivePath(hdr.Name)
+		if !ok {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if _, err := m.dir(name, hdr.ModTime); err != nil {
+				limits.skipped(name, err.Error())
+			}
+		case tar.TypeReg:
+			// 読み込まないエントリは、次の Next で読み飛ばされる
+			if !limits.admit(name, hdr.Size) {
+				continue
+			}
+			data, err := limits.read(name, tr, hdr.Size)
+			if err != nil {
+				return nil, err
+			}
+			if err := m.file(name, data, hdr.FileInfo().Mode(), hdr.ModTime); err != nil {
+				limits.skipped(name, err.Error())
+			}
+		}
+	}
+}
+
+// archivePath は、アーカイブのエントリ名を FS 内のパスに変換します
+// ".." を含むなど、アーカイブの外を指すパスの場合は false を返します
+func archivePath(name string) (string, bool) {
+	name = path.Clean(strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "/"))
+	return name, fs.ValidPath(name) && name != "."
+}
+
+// memFS は、メモリ上に保持したファイルの読み込み専用の fs.FS
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+// memEntry は、memFS のファイルまたはディレクトリ
+type memEntry struct {
+	name     string // ファイル名 (ルートは ".")
+	data     []byte
+	mode     fs.FileMode
+	modTime  time.Time
+	children map[string]*memEntry // ディレクトリの場合のみ
+}
+
+func newMemFS() *memFS {
+	root := &memEntry{name: ".", mode: fs.ModeDir | 0555, children: map[string]*memEntry{}}
+	return &memFS{entries: map[string]*memEntry{".": root}}
+}
+
+// dir は、ディレクトリを親ディレクトリも含めて作成します
+// 途中のパスに同じ名前のファイルがある場合はエラーを返します
+func (m *memFS) dir(name string, modTime time.Time) (*memEntry, error) {
+	if e, ok := m.entries[name]; ok {
+		if !e.IsDir() {
+			return nil, fmt.Errorf("conflicts with file %s", name)
+		}
+		if !modTime.IsZero() {
+			e.modTime = modTime
+		}
+		return e, nil
+	}
+	parent, err := m.dir(path.Dir(name), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	e := &memEntry{name: path.Base(name), mode: fs.ModeDir | 0555, modTime: modTime, children: map[string]*memEntry{}}
+	parent.children[e.name] = e
+	m.entries[name] = e
+	return e, nil
+}
+
+// file は、ファイルを作成します (同じ名前のファイルは後のもので置き換えます)
+// 同じ名前のディレクトリがある場合や、途中のパスにファイルがある場合はエラーを返します
+func (m *memFS) file(name string, data []byte, mode fs.FileMode, modTime time.Time) error {
+	if e, ok := m.entries[name]; ok && e.IsDir() {
+		return fmt.Errorf("conflicts with directory %s", name)
+	}
+	parent, err := m.dir(path.Dir(name), time.Time{})
+	if err != nil {
+		return err
+	}
+	e := &memEntry{name: path.Base(name), data: data, mode: mode.Perm(), modTime: modTime}
+	parent.children[e.name] = e
+	m.entries[name] = e
+	return nil
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if e.IsDir() {
+		return &memDir{entry: e, list: e.list()}, nil
+	}
+	return &memFile{entry: e, Reader: bytes.NewReader(e.data)}, nil
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, ok := m.entries[name]
+	if !ok || !e.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return e.list(), nil
+}
+
+// list は、ディレクトリの内容を名前順に返します
+func (e *memEntry) list() []fs.DirEntry {
+	list := make([]fs.DirEntry, 0, len(e.children))
+	for _, c := range e.children {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// fs.FileInfo と fs.DirEntry の実装
+func (e *memEntry) Name() string               { return e.name }
+func (e *memEntry) Size() int64                { return int64(len(e.data)) }
+func (e *memEntry) Mode() fs.FileMode          { return e.mode }
+func (e *memEntry) ModTime() time.Time         { return e.modTime }
+func (e *memEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *memEntry) Sys() any                   { return nil }
+func (e *memEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *memEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// memFile は、memFS で開いたファイル
+type memFile struct {
+	entry *memEntry
+	*bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir は、memFS で開いたディレクトリ
+type memDir struct {
+	entry *memEntry
+	list  []fs.DirEntry
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: fs.ErrInvalid}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		list := d.list
+		d.list = nil
+		return list, nil
+	}
+	if len(d.list) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.list))
+	list := d.list[:n]
+	d.list = d.list[n:]
+	return list, nil
+}
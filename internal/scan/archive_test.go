@@ -0,0 +1,242 @@
+package scan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// アーカイブに含めるテスト用のファイル
+var archiveFiles = []struct {
+	name, content string
+}{
+	{"project/.gitattributes", "*.tmpl linguist-language=Go-Template\n"},
+	{"project/main.go", "package main\n"},
+	{"project/web/page.tmpl", "{{ .Title }}\n"},
+	{"project/.env", "SECRET=1\n"},
+	{"project/.git/HEAD", "ref: refs/heads/main\n"},
+	{"project/node_modules/x/index.js", "module.exports = 1\n"},
+	{"project/keys/id_rsa", "-----BEGIN\n"},
+	{"../evil.go", "package evil\n"},
+}
+
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatalf("zip の作成に失敗: %v", err)
+		}
+		io.WriteString(w, f.content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip の作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+}
+
+func writeTar(t *testing.T, path string, compress bool) {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	tw.WriteHeader(&tar.Header{Name: "project/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, f := range archiveFiles {
+		tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.content))})
+		io.WriteString(tw, f.content)
+	}
+	tw.WriteHeader(&tar.Header{Name: "project/link.go", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"})
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar の作成に失敗: %v", err)
+	}
+	if gz != nil {
+		gz.Close()
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+}
+
+// アーカイブの内容がディレクトリと同じ規則で収集されることを検証
+func TestGatherArchives(t *testing.T) {
+	tempDir := t.TempDir()
+	writeZip(t, filepath.Join(tempDir, "code.zip"))
+	writeTar(t, filepath.Join(tempDir, "code.tar"), false)
+	writeTar(t, filepath.Join(tempDir, "code.tar.gz"), true)
+	writeTar(t, filepath.Join(tempDir, "code.tgz"), true)
+
+	for _, name := range []string{"code.zip", "code.tar", "code.tar.gz", "code.tgz"} {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(tempDir, name)
+			var log strings.Builder
+			got, err := Gather([]string{archive}, Options{ApplyDefaultIgnores: true, Logger: testLogger(&log)})
+			if err != nil {
+				t.Fatalf("Gather() エラー: %v", err)
+			}
+
+			expected := map[string]struct{ lang, content string }{
+				filepath.Join(archive, "project/main.go"):       {"", "package main\n"},
+				filepath.Join(archive, "project/web/page.tmpl"): {"Go-Template", "{{ .Title }}\n"},
+			}
+			if len(got) != len(expected) {
+				t.Fatalf("Gather() = %v\n%s", got, log.String())
+			}
+			for _, f := range got {
+				e, ok := expected[f.Path]
+				if !ok || f.Lang != e.lang {
+					t.Errorf("予期しないファイル: %+v", f)
+					continue
+				}
+				r, err := f.Open()
+				if err != nil {
+					t.Fatalf("%s: Open() エラー: %v", f.Path, err)
+				}
+				content, _ := io.ReadAll(r)
+				r.Close()
+				if string(content) != e.content {
+					t.Errorf("%s の内容 = %q, expected %q", f.Path, content, e.content)
+				}
+			}
+
+			// 除外の理由はアーカイブ内のパスで出力される
+			if !strings.Contains(log.String(), filepath.Join(archive, "project/keys/id_rsa")) {
+				t.Errorf("機密ファイルの除外が出力されていません:\n%s", log.String())
+			}
+		})
+	}
+
+	// アーカイブのファイル名にも無視パターンを適用する
+	got, err := Gather([]string{filepath.Join(tempDir, "code.zip")}, Options{UserIgnorePatterns: []string{"*.zip"}})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Gather() = (%v, %v), expected 0 件", got, err)
+	}
+
+	// 壊れたアーカイブはスキップする
+	broken := filepath.Join(tempDir, "broken.tgz")
+	if err := os.WriteFile(broken, []byte("not gzip"), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+	got, err = Gather([]string{broken}, Options{})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Gather() = (%v, %v), expected 0 件", got, err)
+	}
+}
+
+// tar から作成した memFS が fs.FS の規約を満たすことを検証
+func TestMemFS(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "code.tar")
+	writeTar(t, path, false)
+
+	fsys, err := openArchive(path, &archiveLimits{maxTotal: archiveBudget})
+	if err != nil {
+		t.Fatalf("openArchive() エラー: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "project/main.go", "project/.git/HEAD", "project/keys/id_rsa"); err != nil {
+		t.Error(err)
+	}
+	if _, err := fsys.Open("project/link.go"); err == nil {
+		t.Error("シンボリックリンクは含めないはずです")
+	}
+}
+
+// writeTarEntries は、指定した順にエントリを並べた tar を作成します
+// 名前が "/" で終わるエントリはディレクトリとします
+func writeTarEntries(t *testing.T, path string, entries []struct{ name, content string }) {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		if strings.HasSuffix(e.name, "/") {
+			tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeDir, Mode: 0755})
+			continue
+		}
+		tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(e.content))})
+		io.WriteString(tw, e.content)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar の作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+}
+
+// 同じ名前のファイルとディレクトリを含むアーカイブでは、後のエントリをスキップすることを検証
+func TestOpenArchiveConflicts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conflict.tar")
+	writeTarEntries(t, path, []struct{ name, content string }{
+		{"a", "file a\n"},
+		{"a/b.go", "package a\n"},
+		{"c/", ""},
+		{"c", "file c\n"},
+		{"d/e.go", "package d\n"},
+	})
+
+	var skipped []string
+	fsys, err := openArchive(path, &archiveLimits{
+		maxTotal: archiveBudget,
+		skip:     func(name, reason string) { skipped = append(skipped, name) },
+	})
+	if err != nil {
+		t.Fatalf("openArchive() エラー: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "a", "d/e.go"); err != nil {
+		t.Error(err)
+	}
+	if info, err := fs.Stat(fsys, "c"); err != nil || !info.IsDir() {
+		t.Errorf("c はディレクトリのままのはずです: (%v, %v)", info, err)
+	}
+	if strings.Join(skipped, ",") != "a/b.go,c" {
+		t.Errorf("skipped = %v, expected [a/b.go c]", skipped)
+	}
+}
+
+// サイズの上限を超えるエントリは読み込まずにスキップすることを検証
+func TestOpenArchiveLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.tar")
+	writeTarEntries(t, path, []struct{ name, content string }{
+		{"small.go", "package a\n"},
+		{"large.txt", strings.Repeat("x", 100)},
+		{"medium1.txt", strings.Repeat("y", 40)},
+		{"medium2.txt", strings.Repeat("z", 40)},
+	})
+
+	var skipped []string
+	limits := &archiveLimits{
+		maxFile:  50,
+		maxTotal: 60,
+		skip:     func(name, reason string) { skipped = append(skipped, name+": "+reason) },
+	}
+	fsys, err := openArchive(path, limits)
+	if err != nil {
+		t.Fatalf("openArchive() エラー: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "small.go", "medium1.txt"); err != nil {
+		t.Error(err)
+	}
+	expected := []string{
+		"large.txt: size: 100 B exceeds --max-file-size 50 B",
+		"medium2.txt: size: 40 B would exceed the archive limit 60 B",
+	}
+	if strings.Join(skipped, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("skipped = %q, expected %q", skipped, expected)
+	}
+}
@@ -0,0 +1,180 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// 除外した規則
+const (
+	RuleDotfile   = "dotfile"            // '.' で始まるファイル
+	RuleDotdir    = "dotdir"             // '.' で始まるディレクトリ
+	RuleDefault   = "default ignore"     // デフォルトの無視パターン
+	RuleUser      = "user pattern"       // --ignore で指定したパターン
+	RuleVendored  = "vendored directory" // vendor などのディレクトリ
+	RuleSensitive = "sensitive"          // 機密ファイル
+	RuleGenerated = "generated"          // 生成コード・圧縮済みファイル
+	RuleSize      = "size limit"         // --max-file-size
+)
+
+// Decision は、Explain で判定した1つのパスの扱い
+type Decision struct {
+	Included bool   // 収集の対象になるかどうか
+	Rule     string // 除外した規則 (Rule* のいずれか、対象の場合は空)
+	Pattern  string // 一致したパターン (該当する場合)
+	Path     string // 規則を適用したパス (除外された祖先のディレクトリの場合もある)
+	Reason   string // 補足 (機密ファイルの種類、生成コードの理由、サイズなど。要約のみを出力する生成コードは対象でも設定)
+	Hint     string // 対象に含めるためのオプション
+}
+
+// Explain は、root を指定して Gather を実行した場合に target がどう扱われるかを、
+// Gather と同じ順序で規則を確認して返します
+// target が root の外にある場合は、target を直接指定した場合の扱いを返します
+//
+// ファイルの内容による判定 (バイナリ、生成コードのヘッダーなど) と
+// --max-total-size は収集の順序に依存するため対象外です
+func Explain(root, target string, opt Options) (Decision, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return Decision{}, err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return Decision{}, err
+	}
+	info, err := os.Stat(absTarget)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	// --max-total-size は収集の順序に依存するため判定しない
+	opt.MaxTotalSize = 0
+	g := newGatherer(opt)
+	volume := filepath.VolumeName(absTarget) + string(filepath.Separator)
+	attrs := newGitAttributes(os.DirFS(volume))
+	fsName := func(p string) string {
+		name := filepath.ToSlash(strings.TrimPrefix(p, volume))
+		if name == "" {
+			return "."
+		}
+		return name
+	}
+	dirDecision := func(dir string, walked bool) Decision {
+		d := g.decideDir(filepath.Base(dir), walked)
+		d.Path = dir
+		return d
+	}
+
+	// root から target までのディレクトリを探索と同じ順に確認する
+	rel, err := filepath.Rel(absRoot, absTarget)
+	walked := err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	if walked {
+		// 指定したディレクトリ自体の確認
+		if d := dirDecision(absRoot, false); !d.Included {
+			return d, nil
+		}
+		attrs.loadAncestors(fsName(absRoot))
+		dir := absRoot
+		parts := strings.Split(rel, string(filepath.Separator))
+		for _, part := range parts[:len(parts)-1] {
+			dir = filepath.Join(dir, part)
+			if d := dirDecision(dir, true); !d.Included {
+				return d, nil
+			}
+			attrs.loadDir(fsName(dir))
+		}
+	}
+
+	if info.IsDir() {
+		// ディレクトリの場合は、その中が探索の対象になるかを返す
+		return dirDecision(absTarget, walked), nil
+	}
+	if !walked {
+		attrs.loadAncestors(path.Dir(fsName(absTarget)))
+	}
+
+	d := g.decideFile(fsName(absTarget), info, attrs)
+	d.Path = absTarget
+	return d, nil
+}
+
+// decideFile は、FS 内のファイル name を収集の対象にするかを、Gather と Explain で共通の規則で判定します
+// 対象とする場合はサイズを g.limiter の合計に加算します
+// 生成コードの要約のみを出力する場合は、対象としたうえで Reason に理由を設定します
+func (g *gatherer) decideFile(name string, info fs.FileInfo, attrs *gitAttributes) Decision {
+	opt := g.opt
+	fileName := path.Base(name)
+
+	var d Decision
+	if !opt.IncludeDotfiles && len(fileName) > 0 && fileName[0] == '.' {
+		d.Rule, d.Hint = RuleDotfile, "--include-dotfiles"
+		return d
+	}
+	if p, ok := matchIgnore(fileName, g.ignore); ok {
+		d.Rule, d.Pattern = ignoreRule(p, opt), p
+		d.Hint = ignoreHint(d.Rule)
+		return d
+	}
+	if reason, ok := sensitiveReason(fileName); ok && !opt.AllowSensitive {
+		d.Rule, d.Reason, d.Hint = RuleSensitive, reason, "--allow-sensitive"
+		return d
+	}
+	if !opt.IncludeGenerated {
+		if reason, ok := generatedReason(name, fileName, attrs); ok {
+			if !opt.SummarizeGenerated {
+				d.Rule, d.Reason, d.Hint = RuleGenerated, reason, "--include-generated"
+				return d
+			}
+			d.Reason = reason
+		}
+	}
+	// サイズ上限のチェック (読み込み前に行う)
+	if reason, ok := g.limiter.allow(info.Size()); !ok {
+		d.Rule, d.Reason = RuleSize, reason
+		return d
+	}
+	d.Included = true
+	return d
+}
+
+// decideDir は、ディレクトリ name (ディレクトリ名) の中を探索するかを判定します
+// walked が false の場合は直接指定したディレクトリとして、vendored ディレクトリの確認を行いません
+func (g *gatherer) decideDir(name string, walked bool) Decision {
+	opt := g.opt
+
+	var d Decision
+	if !opt.IncludeDotfiles && len(name) > 0 && name[0] == '.' {
+		d.Rule, d.Hint = RuleDotdir, "--include-dotfiles"
+		return d
+	}
+	if p, ok := matchIgnore(name, g.ignore); ok {
+		d.Rule, d.Pattern = ignoreRule(p, opt), p
+		d.Hint = ignoreHint(d.Rule)
+		return d
+	}
+	if walked && !opt.IncludeGenerated && isVendoredDir(name) {
+		d.Rule, d.Hint = RuleVendored, "--include-generated"
+		return d
+	}
+	d.Included = true
+	return d
+}
+
+// ignoreRule は、一致したパターンが --ignore とデフォルトのどちらのものかを返します
+func ignoreRule(pattern string, opt Options) string {
+	if slices.Contains(opt.UserIgnorePatterns, pattern) {
+		return RuleUser
+	}
+	return RuleDefault
+}
+
+func ignoreHint(rule string) string {
+	if rule == RuleDefault {
+		return "--no-default-ignores"
+	}
+	return ""
+}
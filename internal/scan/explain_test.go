@@ -0,0 +1,101 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Explain が Gather と同じ規則で除外の理由を返すことを検証
+func TestExplain(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":             "package main",
+		"notes.md":            "# notes",
+		".env":                "SECRET=1",
+		".cache/data.go":      "package cache",
+		"node_modules/a/i.js": "module.exports = 1",
+		"vendor/lib/lib.go":   "package lib",
+		"keys/id_rsa":         "-----BEGIN",
+		"api.pb.go":           "package api",
+		"gen/schema.go":       "package gen",
+		"big.txt":             strings.Repeat("x", 2048),
+		".gitattributes":      "gen/** linguist-generated\n",
+		".git/HEAD":           "ref: refs/heads/main",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("ファイル作成に失敗: %v", err)
+		}
+	}
+	opt := Options{
+		UserIgnorePatterns:  []string{"*.md"},
+		ApplyDefaultIgnores: true,
+		MaxFileSize:         1024,
+	}
+
+	tests := []struct {
+		target  string
+		rule    string
+		pattern string
+		path    string // 規則を適用したパス (空の場合は target)
+		hint    string
+	}{
+		{"main.go", "", "", "", ""},
+		{"notes.md", RuleUser, "*.md", "", ""},
+		{".env", RuleDotfile, "", "", "--include-dotfiles"},
+		{".cache/data.go", RuleDotdir, "", ".cache", "--include-dotfiles"},
+		{"node_modules/a/i.js", RuleDefault, "node_modules", "node_modules", "--no-default-ignores"},
+		{"vendor/lib/lib.go", RuleVendored, "", "vendor", "--include-generated"},
+		{"keys/id_rsa", RuleSensitive, "", "", "--allow-sensitive"},
+		{"api.pb.go", RuleGenerated, "", "", "--include-generated"},
+		{"gen/schema.go", RuleGenerated, "", "", "--include-generated"},
+		{"big.txt", RuleSize, "", "", ""},
+		{"vendor", RuleVendored, "", "", "--include-generated"},
+	}
+	for _, tt := range tests {
+		d, err := Explain(tempDir, filepath.Join(tempDir, tt.target), opt)
+		if err != nil {
+			t.Fatalf("Explain(%q) エラー: %v", tt.target, err)
+		}
+		path := tt.path
+		if path == "" {
+			path = tt.target
+		}
+		if d.Included != (tt.rule == "") || d.Rule != tt.rule || d.Pattern != tt.pattern || d.Hint != tt.hint {
+			t.Errorf("Explain(%q) = %+v, expected rule=%q pattern=%q hint=%q", tt.target, d, tt.rule, tt.pattern, tt.hint)
+		}
+		if d.Path != filepath.Join(tempDir, path) {
+			t.Errorf("Explain(%q).Path = %q, expected %q", tt.target, d.Path, filepath.Join(tempDir, path))
+		}
+	}
+
+	// 直接指定した vendored ディレクトリの中は探索の対象になる
+	d, err := Explain(filepath.Join(tempDir, "vendor"), filepath.Join(tempDir, "vendor/lib/lib.go"), opt)
+	if err != nil || !d.Included {
+		t.Errorf("Explain(vendor/lib/lib.go) = (%+v, %v), expected included", d, err)
+	}
+
+	// root の外にあるファイルは直接指定した場合の扱いを返す
+	d, err = Explain(filepath.Join(tempDir, "keys"), filepath.Join(tempDir, "gen/schema.go"), opt)
+	if err != nil || d.Rule != RuleGenerated || d.Reason != "linguist-generated" {
+		t.Errorf("Explain(gen/schema.go) = (%+v, %v), expected rule=%q", d, err, RuleGenerated)
+	}
+
+	// 要約のみを出力する生成コードは対象に含め、理由を返す
+	summarize := opt
+	summarize.SummarizeGenerated = true
+	d, err = Explain(tempDir, filepath.Join(tempDir, "api.pb.go"), summarize)
+	if err != nil || !d.Included || d.Reason != "protobuf generated" {
+		t.Errorf("Explain(api.pb.go) = (%+v, %v), expected included with reason", d, err)
+	}
+
+	if _, err := Explain(tempDir, filepath.Join(tempDir, "missing.go"), opt); err == nil {
+		t.Error("存在しないパスはエラーを返すべきです")
+	}
+}
@@ -0,0 +1,59 @@
+package scan
+
+import (
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// 生成コードや圧縮済みファイルとみなすファイル名のパターン
+var generatedPatterns = []struct {
+	pattern string
+	reason  string
+}{
+	{"*.pb.go", "protobuf generated"},
+	{"*.pb.gw.go", "protobuf generated"},
+	{"*_pb2.py", "protobuf generated"},
+	{"*_pb2_grpc.py", "protobuf generated"},
+	{"*.min.js", "minified"},
+	{"*.min.css", "minified"},
+	{"*.js.map", "source map"},
+	{"*.css.map", "source map"},
+}
+
+// 外部から取り込んだコードとみなすディレクトリ名
+var vendoredDirs = []string{
+	"vendor",
+	"third_party",
+	"third-party",
+	"bower_components",
+}
+
+// generatedReason は、ファイルが生成コード・圧縮済み・vendored とみなされる場合にその理由を返します
+// ファイル名のパターンと .gitattributes の linguist-generated / linguist-vendored 属性を参照します
+func generatedReason(path, name string, attrs *gitAttributes) (string, bool) {
+	// .gitattributes での明示的な指定を優先
+	if v := attrs.get(path, "linguist-generated"); v == "false" {
+		return "", false
+	} else if isTrue(v) {
+		return "linguist-generated", true
+	}
+	if isTrue(attrs.get(path, "linguist-vendored")) {
+		return "linguist-vendored", true
+	}
+
+	for _, g := range generatedPatterns {
+		if ok, _ := doublestar.Match(g.pattern, name); ok {
+			return g.reason, true
+		}
+	}
+	return "", false
+}
+
+// isVendoredDir は、ディレクトリ名が vendored ディレクトリに一致するか確認します
+func isVendoredDir(name string) bool {
+	for _, d := range vendoredDirs {
+		if name == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package scan
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// attrRule は、.gitattributes の1行分のルール
+type attrRule struct {
+	base    string            // .gitattributes が置かれたディレクトリ (FS 内のパス)
+	pattern string            // パスパターン
+	attrs   map[string]string // 属性名と値 ("true", "false", または任意の値)
+}
+
+// gitAttributes は、1つのファイルシステムから読み込んだ .gitattributes のルールを保持します
+// 後から追加されたルールほど優先されるため、親ディレクトリから順に読み込みます
+type gitAttributes struct {
+	fsys   fs.FS
+	rules  []attrRule
+	loaded map[string]bool
+
+	// rootIsRepo が true の場合、.git が見つからなくても FS のルートをリポジトリのルートとみなします
+	// (GatherFS では FS 全体を1つのリポジトリとして扱う)
+	rootIsRepo bool
+}
+
+func newGitAttributes(fsys fs.FS) *gitAttributes {
+	return &gitAttributes{fsys: fsys, loaded: map[string]bool{}}
+}
+
+// loadDir は、ディレクトリ直下の .gitattributes を読み込みます (一度だけ)
+func (g *gitAttributes) loadDir(dir string) {
+	if g.loaded[dir] {
+		return
+	}
+	g.loaded[dir] = true
+
+	f, err := g.fsys.Open(path.Join(dir, ".gitattributes"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		attrs := map[string]string{}
+		for _, a := range fields[1:] {
+			switch {
+			case strings.HasPrefix(a, "-"):
+				attrs[a[1:]] = "false"
+			case strings.HasPrefix(a, "!"):
+				attrs[a[1:]] = ""
+			case strings.Contains(a, "="):
+				k, v, _ := strings.Cut(a, "=")
+				attrs[k] = v
+			default:
+				attrs[a] = "true"
+			}
+		}
+		g.rules = append(g.rules, attrRule{base: dir, pattern: fields[0], attrs: attrs})
+	}
+}
+
+// loadAncestors は、リポジトリのルート (.git を含むディレクトリ) から
+// 指定ディレクトリまでの .gitattributes を親から順に読み込みます
+func (g *gitAttributes) loadAncestors(dir string) {
+	var chain []string
+	for d := dir; ; d = path.Dir(d) {
+		chain = append(chain, d)
+		if _, err := fs.Stat(g.fsys, path.Join(d, ".git")); err == nil {
+			break
+		}
+		if path.Dir(d) == d {
+			// リポジトリ外の場合は指定ディレクトリのみを対象とする
+			if !g.rootIsRepo {
+				chain = chain[:1]
+			}
+			break
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		g.loadDir(chain[i])
+	}
+}
+
+// get は、ファイル (FS 内のパス) に対する属性の値を返します (未指定の場合は空文字列)
+func (g *gitAttributes) get(name, attr string) string {
+	value := ""
+	for _, r := range g.rules {
+		v, ok := r.attrs[attr]
+		if !ok || !r.match(name) {
+			continue
+		}
+		value = v
+	}
+	return value
+}
+
+// match は、ルールのパターンがファイル (FS 内のパス) に一致するか確認します
+// '/' を含まないパターンは任意の階層のファイル名と照合します
+func (r attrRule) match(name string) bool {
+	rel := name
+	if r.base != "." {
+		var ok bool
+		if rel, ok = strings.CutPrefix(name, r.base+"/"); !ok {
+			return false
+		}
+	}
+
+	pattern := r.pattern
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		ok, _ := doublestar.Match(pattern, path.Base(name))
+		return ok
+	}
+	ok, _ := doublestar.Match(strings.TrimPrefix(pattern, "/"), rel)
+	return ok
+}
+
+// isTrue は、属性値が有効 (set) かどうかを返します
+func isTrue(v string) bool {
+	return v == "true" || v == "1"
+}
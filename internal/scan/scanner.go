@@ -1,14 +1,16 @@
 package scan
 
 import (
-	"fmt"
+	"context"
 	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
-	"unicode/utf8"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/your-org/code2md/internal/logging"
 )
 
 // デフォルトで無視するディレクトリ名のパターン
@@ -20,15 +22,94 @@ var defaultIgnore = []string{
 	"node_modules",
 }
 
+// 機密情報を含む可能性が高いファイル名のパターンと、除外する理由
+// defaultIgnore とは異なり、--no-default-ignores では無効にならず、
+// AllowSensitive を指定した場合のみ出力対象に含めます
+var sensitivePatterns = []struct {
+	pattern string
+	reason  string
+}{
+	{"id_rsa", "SSH private key"},
+	{"id_dsa", "SSH private key"},
+	{"id_ecdsa", "SSH private key"},
+	{"id_ed25519", "SSH private key"},
+	{"*.pem", "PEM key or certificate"},
+	{"*.key", "private key"},
+	{"*.p12", "PKCS#12 keystore"},
+	{"*.pfx", "PKCS#12 keystore"},
+	{"*.jks", "Java keystore"},
+	{"*.keystore", "keystore"},
+	{".env", "environment file"},
+	{".env.*", "environment file"},
+	{"*.env", "environment file"},
+	{"credentials.json", "credentials file"},
+	{"credentials", "credentials file"},
+	{"client_secret*.json", "OAuth client secret"},
+	{".git-credentials", "credentials file"},
+	{".netrc", "credentials file"},
+	{".pgpass", "credentials file"},
+	{".htpasswd", "password file"},
+	{"*.kdbx", "password database"},
+	{"*.tfstate", "Terraform state"},
+	{"*.tfstate.backup", "Terraform state"},
+}
+
+// 機密ファイルのパターンに一致しても、サンプルとして扱うファイルの接尾辞
+var sensitiveExceptions = []string{
+	".example",
+	".sample",
+	".template",
+	".dist",
+}
+
 // Options は、ファイル探索の設定オプション
 type Options struct {
 	UserIgnorePatterns  []string
 	IncludeDotfiles     bool
 	ApplyDefaultIgnores bool
+	AllowSensitive      bool
+	MaxFileSize         int64        // 1ファイルあたりの最大バイト数 (0は無制限)
+	MaxTotalSize        int64        // 出力対象ファイルの合計最大バイト数 (0は無制限)
+	IncludeGenerated    bool         // 生成コード・圧縮済みファイル・vendored ディレクトリも含める
+	SummarizeGenerated  bool         // 生成コード・圧縮済みファイルを除外せずに要約の対象とする (vendored ディレクトリは除外)
+	Logger              *slog.Logger // 除外したファイルや警告の出力先 (nil の場合は出力しない)
+}
+
+// File は、Gather が収集したファイル
+type File struct {
+	Path string // 表示に使用するパス (OS上のファイルは絶対パス、GatherFS では FS 内のパス)
+	Lang string // .gitattributes の linguist-language で指定された言語 (未指定の場合は空)
+	// Generated は、ファイル名や .gitattributes から生成コードと判断した理由
+	// (Options.SummarizeGenerated の場合のみ設定され、内容の代わりに要約を出力します)
+	Generated string
+	FS        fs.FS  // ファイルを含むファイルシステム (nil の場合は Path をOS上のパスとして扱う)
+	Name      string // FS 内のパス
+}
+
+// Open は、ファイルを読み込み用に開きます
+func (f File) Open() (fs.File, error) {
+	if f.FS == nil {
+		return os.Open(f.Path)
+	}
+	return f.FS.Open(f.Name)
+}
+
+// Stat は、ファイルの情報を返します
+func (f File) Stat() (fs.FileInfo, error) {
+	if f.FS == nil {
+		return os.Stat(f.Path)
+	}
+	return fs.Stat(f.FS, f.Name)
 }
 
 // isIgnored は、指定された名前がパターンのいずれかに一致するか確認します
 func isIgnored(name string, patterns []string) bool {
+	_, ok := matchIgnore(name, patterns)
+	return ok
+}
+
+// matchIgnore は、指定された名前に最初に一致したパターンを返します
+func matchIgnore(name string, patterns []string) (string, bool) {
 	for _, p := range patterns {
 		// doublestarは完全なパスパターンを期待するため、
 		// 単純なファイル名パターンの場合は特殊処理
@@ -36,16 +117,31 @@ func isIgnored(name string, patterns []string) bool {
 			// ワイルドカードを含むパターン
 			ok, _ := doublestar.Match(p, name)
 			if ok {
-				return true
+				return p, true
 			}
 		} else {
 			// 完全一致またはファイル名に含まれるパターン
 			if p == name || strings.Contains(name, p) {
-				return true
+				return p, true
 			}
 		}
 	}
-	return false
+	return "", false
+}
+
+// sensitiveReason は、ファイル名が機密ファイルのパターンに一致する場合にその理由を返します
+func sensitiveReason(name string) (string, bool) {
+	for _, suffix := range sensitiveExceptions {
+		if strings.HasSuffix(name, suffix) {
+			return "", false
+		}
+	}
+	for _, s := range sensitivePatterns {
+		if ok, _ := doublestar.Match(s.pattern, name); ok {
+			return s.reason, true
+		}
+	}
+	return "", false
 }
 
 // getRelativePath は、指定されたパスを現在の作業ディレクトリからの相対パスに変換します
@@ -65,127 +161,234 @@ func getRelativePath(absPath string) string {
 	return relPath
 }
 
-// getFileStats は、ファイルの行数、単語数、文字数を計算します
-func getFileStats(path string) (lines, words, chars int, err error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return 0, 0, 0, err
-	}
-
-	content := string(data)
-	lines = len(strings.Split(content, "\n"))
-	words = len(strings.Fields(content))
-	chars = utf8.RuneCountInString(content)
-
-	return lines, words, chars, nil
+// Gather は、指定されたパスから条件に一致するファイルのリストを収集します
+func Gather(paths []string, opt Options) ([]File, error) {
+	return GatherContext(context.Background(), paths, opt)
 }
 
-// Gather は、指定されたパスから条件に一致するファイルのリストを収集します
-func Gather(paths []string, opt Options) ([]string, error) {
-	// 無視パターンの準備
-	ignore := append([]string{}, opt.UserIgnorePatterns...)
-	if opt.ApplyDefaultIgnores {
-		ignore = append(ignore, defaultIgnore...)
-	}
+// GatherContext は、Gather と同様にファイルを収集します
+// ctx がキャンセルされた場合は探索を中断し、ctx.Err() を返します
+// .zip, .tar, .tar.gz, .tgz のパスはメモリ上に展開し、その内容をディレクトリと同じ規則で収集します
+//
+// 各パスはボリュームのルートを基点とする os.DirFS 上で探索するため、
+// 指定したディレクトリより上位の .gitattributes も参照できます
+func GatherContext(ctx context.Context, paths []string, opt Options) ([]File, error) {
+	g := newGatherer(opt)
+	attrs := map[string]*gitAttributes{} // ボリュームのルートごと
+	fsyss := map[string]fs.FS{}
 
-	var out []string
 	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		// 絶対パスに変換
 		absPath, err := filepath.Abs(p)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Error resolving path '%s': %v. Skipping.\n", p, err)
+			g.log.Error("could not resolve path", "path", p, "err", err)
 			continue
 		}
 
-		// パスの存在確認
-		info, err := os.Stat(absPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Path '%s' not found. Skipping.\n", p)
+		// アーカイブは展開してその内容を収集する
+		if info, err := os.Stat(absPath); err == nil && info.Mode().IsRegular() && archiveKind(absPath) != "" {
+			if err := g.gatherArchive(ctx, p, absPath); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
-		// ファイルの場合は直接追加
-		if !info.IsDir() {
-			// ドットファイルチェック
-			name := filepath.Base(absPath)
-			if !opt.IncludeDotfiles && len(name) > 0 && name[0] == '.' {
-				continue
-			}
+		// ボリュームのルートからの相対パスを FS 内のパスとする
+		root := filepath.VolumeName(absPath) + string(filepath.Separator)
+		name := filepath.ToSlash(strings.TrimPrefix(absPath, root))
+		if name == "" {
+			name = "."
+		}
+		if fsyss[root] == nil {
+			fsyss[root] = os.DirFS(root)
+			attrs[root] = newGitAttributes(fsyss[root])
+		}
+		display := func(name string) string {
+			return filepath.Join(root, filepath.FromSlash(name))
+		}
 
-			// ファイル名がパターンに一致するかチェック
-			if isIgnored(name, ignore) {
-				fmt.Fprintf(os.Stderr, "Ignored (file pattern): %s\n", absPath)
-				continue
-			}
+		if err := g.gather(ctx, fsyss[root], attrs[root], p, name, display); err != nil {
+			return nil, err
+		}
+	}
 
-			// ファイルの統計情報を取得して表示
-			if lines, words, chars, err := getFileStats(absPath); err == nil {
-				fmt.Fprintf(os.Stderr, "Loading %s (%d lines, %d words, %d characters)\n", getRelativePath(absPath), lines, words, chars)
-			} else {
-				fmt.Fprintf(os.Stderr, "Loading %s\n", getRelativePath(absPath))
-			}
+	g.summary()
+	return g.out, nil
+}
 
-			out = append(out, absPath)
-			continue
-		}
+// GatherFS は、ファイルシステム fsys 内の roots (ファイルまたはディレクトリ) から
+// 条件に一致するファイルのリストを収集します。roots が空の場合は FS 全体を探索します
+// 収集したファイルの Path には FS 内のパスが設定されます
+// .gitattributes は FS のルートをリポジトリのルートとみなして読み込みます
+func GatherFS(ctx context.Context, fsys fs.FS, roots []string, opt Options) ([]File, error) {
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	g := newGatherer(opt)
+	attrs := newGitAttributes(fsys)
+	attrs.rootIsRepo = true
+	display := func(name string) string { return name }
 
-		// ディレクトリ自体がパターンに一致するかチェック
-		dirName := filepath.Base(absPath)
-		if !opt.IncludeDotfiles && len(dirName) > 0 && dirName[0] == '.' {
-			fmt.Fprintf(os.Stderr, "Ignored (dotdir): %s\n", p)
+	for _, root := range roots {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name := path.Clean(filepath.ToSlash(root))
+		if !fs.ValidPath(name) {
+			g.log.Error("invalid path", "path", root)
 			continue
 		}
+		if err := g.gather(ctx, fsys, attrs, root, name, display); err != nil {
+			return nil, err
+		}
+	}
 
-		// ディレクトリ自体がパターンに一致するかチェック
-		if isIgnored(dirName, ignore) {
-			fmt.Fprintf(os.Stderr, "Ignored (directory pattern): %s\n", p)
-			continue
+	g.summary()
+	return g.out, nil
+}
+
+// gatherer は、1回の探索で共有する状態
+type gatherer struct {
+	opt     Options
+	log     *slog.Logger
+	ignore  []string
+	limiter *sizeLimiter
+	out     []File
+}
+
+func newGatherer(opt Options) *gatherer {
+	// 無視パターンの準備
+	ignore := append([]string{}, opt.UserIgnorePatterns...)
+	if opt.ApplyDefaultIgnores {
+		ignore = append(ignore, defaultIgnore...)
+	}
+	return &gatherer{
+		opt:     opt,
+		log:     logging.OrDiscard(opt.Logger),
+		ignore:  ignore,
+		limiter: &sizeLimiter{maxFile: opt.MaxFileSize, maxTotal: opt.MaxTotalSize},
+	}
+}
+
+// gather は、fsys 内の name (ユーザーが arg として指定したパス) から対象のファイルを集めます
+// display は、FS 内のパスをメッセージと File.Path に使用するパスに変換します
+func (g *gatherer) gather(ctx context.Context, fsys fs.FS, attrs *gitAttributes, arg, name string, display func(string) string) error {
+	// パスの存在確認
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		g.log.Error("path not found", "path", arg)
+		return nil
+	}
+
+	// ファイルの場合は直接追加
+	if !info.IsDir() {
+		attrs.loadAncestors(path.Dir(name))
+		g.addFile(ctx, fsys, attrs, name, info, display, true)
+		return nil
+	}
+
+	// ディレクトリ自体がパターンに一致するかチェック
+	if name != "." {
+		switch d := g.decideDir(path.Base(name), false); d.Rule {
+		case RuleDotdir:
+			g.log.Info("ignored", "path", arg, "reason", "dotdir")
+			return nil
+		case RuleUser, RuleDefault:
+			g.log.Info("ignored", "path", arg, "reason", "directory pattern")
+			return nil
 		}
+	}
 
-		// ディレクトリを再帰的に探索
-		if err := filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Error accessing '%s': %v. Skipping.\n", path, err)
-				return nil // エラーを無視して続行
-			}
+	attrs.loadAncestors(name)
 
-			name := d.Name()
+	// ディレクトリを再帰的に探索
+	err = fs.WalkDir(fsys, name, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			g.log.Error("could not access path", "path", display(p), "err", err)
+			return nil // エラーを無視して続行
+		}
 
-			// dotfile / dotdir
-			if !opt.IncludeDotfiles && len(name) > 0 && name[0] == '.' {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+		// 指定されたディレクトリ自体は確認済み
+		if p == name {
+			return nil
+		}
 
-			// ignore pattern - ディレクトリの場合
-			if d.IsDir() && isIgnored(name, ignore) {
-				fmt.Fprintf(os.Stderr, "Ignored (directory): %s\n", path)
-				return filepath.SkipDir
+		if d.IsDir() {
+			switch dd := g.decideDir(d.Name(), true); dd.Rule {
+			case RuleDotdir:
+				return fs.SkipDir
+			case RuleUser, RuleDefault:
+				g.log.Debug("ignored", "path", display(p), "reason", "directory")
+				return fs.SkipDir
+			case RuleVendored:
+				g.log.Debug("ignored", "path", display(p), "reason", "vendored directory", "hint", "use --include-generated to include it")
+				return fs.SkipDir
 			}
+			attrs.loadDir(p)
+			return nil
+		}
 
-			// ファイルの場合もパターンチェック
-			if !d.IsDir() {
-				// ファイル名がパターンに一致するかチェック
-				if isIgnored(name, ignore) {
-					fmt.Fprintf(os.Stderr, "Ignored (file): %s\n", path)
-					return nil
-				}
-
-				// ファイルの統計情報を取得して表示
-				if lines, words, chars, err := getFileStats(path); err == nil {
-					fmt.Fprintf(os.Stderr, "Loading %s (%d lines, %d words, %d characters)\n", getRelativePath(path), lines, words, chars)
-				} else {
-					fmt.Fprintf(os.Stderr, "Loading %s\n", getRelativePath(path))
-				}
-
-				out = append(out, path)
-			}
+		info, err := d.Info()
+		if err != nil {
+			g.log.Error("could not access path", "path", display(p), "err", err)
 			return nil
-		}); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Error exploring directory '%s': %v\n", absPath, err)
 		}
+		g.addFile(ctx, fsys, attrs, p, info, display, false)
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		g.log.Error("could not explore directory", "path", display(name), "err", err)
+	}
+	return nil
+}
+
+// addFile は、ファイルが除外の条件に当てはまらなければ収集結果に加えます
+// explicit は、ユーザーが直接指定したファイルかどうかで、除外した場合は Info レベルで出力します
+func (g *gatherer) addFile(ctx context.Context, fsys fs.FS, attrs *gitAttributes, name string, info fs.FileInfo, display func(string) string, explicit bool) {
+	kind, level := "file", slog.LevelDebug
+	if explicit {
+		kind, level = "file pattern", slog.LevelInfo
+	}
+	shown := display(name)
+
+	d := g.decideFile(name, info, attrs)
+	switch d.Rule {
+	case RuleUser, RuleDefault:
+		g.log.Log(ctx, level, "ignored", "path", shown, "reason", kind)
+	case RuleSensitive:
+		g.log.Info("ignored", "path", shown, "reason", "sensitive: "+d.Reason, "hint", "use --allow-sensitive to include it")
+	case RuleGenerated:
+		g.log.Log(ctx, level, "ignored", "path", shown, "reason", "generated: "+d.Reason, "hint", "use --include-generated to include it")
+	case RuleSize:
+		g.log.Log(ctx, level, "ignored", "path", shown, "reason", "size: "+d.Reason)
+	}
+	if !d.Included {
+		return
+	}
+
+	g.out = append(g.out, File{
+		Path:      shown,
+		Lang:      attrs.get(name, "linguist-language"),
+		FS:        fsys,
+		Name:      name,
+		Generated: d.Reason,
+	})
+}
+
+// summary は、サイズ上限で除外したファイルの集計を出力します
+func (g *gatherer) summary() {
+	if g.limiter.skipped > 0 {
+		g.log.Info("excluded files due to size limits", "files", g.limiter.skipped, "size", FormatSize(g.limiter.skippedBytes))
 	}
-	return out, nil
 }
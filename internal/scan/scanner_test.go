@@ -1,12 +1,25 @@
 package scan
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/your-org/code2md/internal/logging"
 )
 
+// testLogger は、すべてのレベルのメッセージを w に出力するロガーを返します
+func testLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: logging.LevelTrace}))
+}
+
 func TestIsIgnored(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -268,7 +281,7 @@ func TestGatherWithDotfiles(t *testing.T) {
 			for _, expected := range tt.shouldContain {
 				found := false
 				for _, file := range files {
-					if file == expected {
+					if file.Path == expected {
 						found = true
 						break
 					}
@@ -281,7 +294,7 @@ func TestGatherWithDotfiles(t *testing.T) {
 			// 含まれるべきでないファイルの確認
 			for _, unexpected := range tt.shouldNotContain {
 				for _, file := range files {
-					if file == unexpected {
+					if file.Path == unexpected {
 						t.Errorf("ファイル %s が結果に含まれていますが、含まれるべきではありません", unexpected)
 						break
 					}
@@ -402,7 +415,7 @@ func TestGatherWithFilePatternIgnore(t *testing.T) {
 			for _, expected := range tt.shouldContain {
 				found := false
 				for _, file := range files {
-					if file == expected {
+					if file.Path == expected {
 						found = true
 						break
 					}
@@ -415,7 +428,7 @@ func TestGatherWithFilePatternIgnore(t *testing.T) {
 			// 含まれるべきでないファイルの確認
 			for _, unexpected := range tt.shouldNotContain {
 				for _, file := range files {
-					if file == unexpected {
+					if file.Path == unexpected {
 						t.Errorf("ファイル %s が結果に含まれていますが、含まれるべきではありません", unexpected)
 						break
 					}
@@ -535,12 +548,12 @@ func TestGatherOutputsRelativePaths(t *testing.T) {
 
 	// 返されるパスは絶対パスのはず
 	expectedAbsPath, _ := filepath.Abs("test.txt")
-	if files[0] != expectedAbsPath {
-		t.Errorf("返されたパス: %s, 期待されるパス: %s", files[0], expectedAbsPath)
+	if files[0].Path != expectedAbsPath {
+		t.Errorf("返されたパス: %s, 期待されるパス: %s", files[0].Path, expectedAbsPath)
 	}
 
 	// getRelativePath関数の動作を個別に確認
-	relativePath := getRelativePath(files[0])
+	relativePath := getRelativePath(files[0].Path)
 	if relativePath != "test.txt" {
 		t.Errorf("相対パス変換結果: %s, 期待値: test.txt", relativePath)
 	}
@@ -593,11 +606,398 @@ func TestGatherIgnoredMessagesUseRelativePaths(t *testing.T) {
 
 	// node_modulesのファイルが結果に含まれていないことを確認
 	for _, file := range files {
-		if strings.Contains(file, "node_modules") {
-			t.Errorf("node_modulesのファイルが結果に含まれています: %s", file)
+		if strings.Contains(file.Path, "node_modules") {
+			t.Errorf("node_modulesのファイルが結果に含まれています: %s", file.Path)
 		}
 	}
 
 	// この時点でIgnoredメッセージが標準エラー出力に出されているはず
 	// （テスト実行時に確認可能）
 }
+
+func TestSensitiveReason(t *testing.T) {
+	tests := []struct {
+		name      string
+		sensitive bool
+	}{
+		{"id_rsa", true},
+		{"id_rsa.pub", false},
+		{"server.pem", true},
+		{"tls.key", true},
+		{".env", true},
+		{".env.production", true},
+		{".env.example", false},
+		{".env.sample", false},
+		{"credentials.json", true},
+		{"client_secret_123.json", true},
+		{"terraform.tfstate", true},
+		{"config.json", false},
+		{"main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := sensitiveReason(tt.name)
+			if ok != tt.sensitive {
+				t.Errorf("sensitiveReason(%q) = (%q, %v), expected %v", tt.name, reason, ok, tt.sensitive)
+			}
+			if ok && reason == "" {
+				t.Errorf("sensitiveReason(%q) は理由を返すべきです", tt.name)
+			}
+		})
+	}
+}
+
+// 機密ファイルが --include-dotfiles でも除外され、AllowSensitive でのみ含まれることを検証
+func TestGatherSensitiveFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"main.go":          "package main",
+		"id_rsa":           "private key",
+		"certs/server.pem": "certificate",
+		".env.production":  "SECRET=1",
+		".env.example":     "SECRET=",
+		"credentials.json": "{}",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("ファイル作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		paths         []string
+		opts          Options
+		expectedCount int
+	}{
+		{
+			name:          "ドットファイルを含めても機密ファイルは除外",
+			paths:         []string{tempDir},
+			opts:          Options{IncludeDotfiles: true, ApplyDefaultIgnores: true},
+			expectedCount: 2, // main.go, .env.example
+		},
+		{
+			name:          "直接指定した機密ファイルも除外",
+			paths:         []string{filepath.Join(tempDir, "id_rsa"), filepath.Join(tempDir, "main.go")},
+			opts:          Options{ApplyDefaultIgnores: true},
+			expectedCount: 1, // main.go
+		},
+		{
+			name:          "AllowSensitive で機密ファイルを含める",
+			paths:         []string{tempDir},
+			opts:          Options{IncludeDotfiles: true, ApplyDefaultIgnores: true, AllowSensitive: true},
+			expectedCount: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Gather(tt.paths, tt.opts)
+			if err != nil {
+				t.Fatalf("Gather() エラー: %v", err)
+			}
+			if len(got) != tt.expectedCount {
+				t.Errorf("ファイル数 = %d, 期待値 %d", len(got), tt.expectedCount)
+				t.Logf("見つかったファイル: %v", got)
+			}
+		})
+	}
+}
+
+// サイズ上限を超えるファイルが除外されることを検証
+func TestGatherSizeLimits(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]int{
+		"a_small.txt":      10,
+		"b_medium.txt":     100,
+		"c_large.txt":      1000,
+		"sub/d_medium.txt": 100,
+	}
+	for path, size := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatalf("ファイル作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		paths         []string
+		opts          Options
+		expectedCount int
+	}{
+		{
+			name:          "制限なし",
+			paths:         []string{tempDir},
+			opts:          Options{ApplyDefaultIgnores: true},
+			expectedCount: 4,
+		},
+		{
+			name:          "ファイルサイズ上限",
+			paths:         []string{tempDir},
+			opts:          Options{ApplyDefaultIgnores: true, MaxFileSize: 500},
+			expectedCount: 3,
+		},
+		{
+			name:          "合計サイズ上限",
+			paths:         []string{tempDir},
+			opts:          Options{ApplyDefaultIgnores: true, MaxTotalSize: 150},
+			expectedCount: 2, // a_small.txt, b_medium.txt
+		},
+		{
+			name:          "直接指定したファイルにも適用",
+			paths:         []string{filepath.Join(tempDir, "c_large.txt")},
+			opts:          Options{ApplyDefaultIgnores: true, MaxFileSize: 500},
+			expectedCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Gather(tt.paths, tt.opts)
+			if err != nil {
+				t.Fatalf("Gather() エラー: %v", err)
+			}
+			if len(got) != tt.expectedCount {
+				t.Errorf("ファイル数 = %d, 期待値 %d", len(got), tt.expectedCount)
+				t.Logf("見つかったファイル: %v", got)
+			}
+		})
+	}
+}
+
+// 生成コード・vendored ファイルの除外と .gitattributes の指定を検証
+func TestGatherGeneratedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"main.go":              "package main",
+		"api.pb.go":            "package api",
+		"static/app.min.js":    "var a=1;",
+		"vendor/lib/lib.go":    "package lib",
+		"gen/schema.go":        "package gen",
+		"gen/keep.go":          "package gen",
+		"legacy/vendor_lib.js": "var b=2;",
+		".gitattributes":       "gen/** linguist-generated\ngen/keep.go -linguist-generated\nlegacy/* linguist-vendored\n",
+		".git/HEAD":            "ref: refs/heads/main",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("ファイル作成に失敗: %v", err)
+		}
+	}
+
+	got, err := Gather([]string{tempDir}, Options{ApplyDefaultIgnores: true})
+	if err != nil {
+		t.Fatalf("Gather() エラー: %v", err)
+	}
+	expected := []string{
+		filepath.Join(tempDir, "gen/keep.go"),
+		filepath.Join(tempDir, "main.go"),
+	}
+	if len(got) != len(expected) || got[0].Path != expected[0] || got[1].Path != expected[1] {
+		t.Errorf("Gather() = %v, expected %v", got, expected)
+	}
+
+	// 直接指定したファイルにも .gitattributes が適用される
+	got, err = Gather([]string{filepath.Join(tempDir, "gen/schema.go")}, Options{ApplyDefaultIgnores: true})
+	if err != nil {
+		t.Fatalf("Gather() エラー: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Gather() = %v, expected no files", got)
+	}
+
+	// IncludeGenerated ですべて含める
+	got, err = Gather([]string{tempDir}, Options{ApplyDefaultIgnores: true, IncludeGenerated: true})
+	if err != nil {
+		t.Fatalf("Gather() エラー: %v", err)
+	}
+	if len(got) != 7 {
+		t.Errorf("ファイル数 = %d, 期待値 7: %v", len(got), got)
+	}
+
+	// SummarizeGenerated では、生成コードを理由とともに残す (vendored ディレクトリは除外)
+	got, err = Gather([]string{tempDir}, Options{ApplyDefaultIgnores: true, SummarizeGenerated: true})
+	if err != nil {
+		t.Fatalf("Gather() エラー: %v", err)
+	}
+	generated := map[string]string{}
+	for _, f := range got {
+		rel, _ := filepath.Rel(tempDir, f.Path)
+		generated[filepath.ToSlash(rel)] = f.Generated
+	}
+	expectedGenerated := map[string]string{
+		"api.pb.go":            "protobuf generated",
+		"static/app.min.js":    "minified",
+		"gen/schema.go":        "linguist-generated",
+		"gen/keep.go":          "",
+		"legacy/vendor_lib.js": "linguist-vendored",
+		"main.go":              "",
+	}
+	if !reflect.DeepEqual(generated, expectedGenerated) {
+		t.Errorf("Gather() = %v, expected %v", generated, expectedGenerated)
+	}
+}
+
+// .gitattributes の linguist-language が収集結果に反映されることを検証
+func TestGatherLinguistLanguage(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		".git/HEAD":          "ref: refs/heads/main",
+		".gitattributes":     "*.tmpl linguist-language=Go-Template\n",
+		"web/.gitattributes": "*.inc linguist-language=PHP\n",
+		"web/page.tmpl":      "{{ .Title }}",
+		"web/header.inc":     "<?php",
+		"main.go":            "package main",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("ファイル作成に失敗: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		filepath.Join(tempDir, "main.go"):        "",
+		filepath.Join(tempDir, "web/header.inc"): "PHP",
+		filepath.Join(tempDir, "web/page.tmpl"):  "Go-Template",
+	}
+
+	got, err := Gather([]string{tempDir}, Options{ApplyDefaultIgnores: true})
+	if err != nil {
+		t.Fatalf("Gather() エラー: %v", err)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Gather() = %v", got)
+	}
+	for _, f := range got {
+		if f.Lang != expected[f.Path] {
+			t.Errorf("%s: Lang = %q, expected %q", f.Path, f.Lang, expected[f.Path])
+		}
+	}
+
+	// 直接指定したファイルにも適用される
+	got, err = Gather([]string{filepath.Join(tempDir, "web/page.tmpl")}, Options{ApplyDefaultIgnores: true})
+	if err != nil {
+		t.Fatalf("Gather() エラー: %v", err)
+	}
+	if len(got) != 1 || got[0].Lang != "Go-Template" {
+		t.Errorf("Gather() = %v", got)
+	}
+}
+
+// キャンセルされたコンテキストでは探索を中断することを検証
+func TestGatherContextCanceled(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	files, err := GatherContext(ctx, []string{tempDir}, Options{ApplyDefaultIgnores: true})
+	if !errors.Is(err, context.Canceled) || files != nil {
+		t.Errorf("GatherContext() = (%v, %v), expected (nil, %v)", files, err, context.Canceled)
+	}
+}
+
+// fs.FS 上でも OS のファイルシステムと同じ規則でファイルを収集することを検証
+func TestGatherFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitattributes":          {Data: []byte("*.tmpl linguist-language=Go-Template\ngen/** linguist-generated\n")},
+		"main.go":                 {Data: []byte("package main")},
+		"web/page.tmpl":           {Data: []byte("{{ .Title }}")},
+		"web/.hidden":             {Data: []byte("secret")},
+		".config/app.yml":         {Data: []byte("a: 1")},
+		"node_modules/x/index.js": {Data: []byte("module.exports = 1")},
+		"gen/api.go":              {Data: []byte("package gen")},
+		"keys/id_rsa":             {Data: []byte("-----BEGIN")},
+		"big.txt":                 {Data: []byte(strings.Repeat("x", 100))},
+	}
+
+	tests := []struct {
+		name     string
+		roots    []string
+		opt      Options
+		expected map[string]string // パス -> linguist-language
+	}{
+		{
+			name:  "デフォルト",
+			roots: nil,
+			opt:   Options{ApplyDefaultIgnores: true, MaxFileSize: 50},
+			expected: map[string]string{
+				"main.go":       "",
+				"web/page.tmpl": "Go-Template",
+			},
+		},
+		{
+			name:  "ドットファイルと生成コードを含める",
+			roots: []string{"."},
+			opt:   Options{ApplyDefaultIgnores: true, IncludeDotfiles: true, IncludeGenerated: true},
+			expected: map[string]string{
+				".gitattributes":  "",
+				".config/app.yml": "",
+				"big.txt":         "",
+				"gen/api.go":      "",
+				"main.go":         "",
+				"web/.hidden":     "",
+				"web/page.tmpl":   "Go-Template",
+			},
+		},
+		{
+			name:  "サブディレクトリとファイルを指定",
+			roots: []string{"web", "keys/id_rsa", "missing"},
+			opt:   Options{ApplyDefaultIgnores: true, AllowSensitive: true},
+			expected: map[string]string{
+				"web/page.tmpl": "Go-Template",
+				"keys/id_rsa":   "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log strings.Builder
+			tt.opt.Logger = testLogger(&log)
+			got, err := GatherFS(context.Background(), fsys, tt.roots, tt.opt)
+			if err != nil {
+				t.Fatalf("GatherFS() エラー: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("GatherFS() = %v, expected %v\n%s", got, tt.expected, log.String())
+			}
+			for _, f := range got {
+				lang, ok := tt.expected[f.Path]
+				if !ok || f.Lang != lang || f.Name != f.Path {
+					t.Errorf("予期しないファイル: %+v", f)
+				}
+				// 収集したファイルは FS から読み込める
+				r, err := f.Open()
+				if err != nil {
+					t.Errorf("%s: Open() エラー: %v", f.Path, err)
+					continue
+				}
+				r.Close()
+			}
+		})
+	}
+}
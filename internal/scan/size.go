@@ -0,0 +1,94 @@
+package scan
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// サイズ指定で使用できる単位 (1KB = 1024 bytes)
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KIB", 1 << 10},
+	{"MIB", 1 << 20},
+	{"GIB", 1 << 30},
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize は、"512", "100KB", "1.5M" のようなサイズ指定をバイト数に変換します
+// 空文字列や "0" は制限なしを表す 0 を返します
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	num, factor := strings.ToUpper(s), int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, factor = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.factor
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	// int64 に変換すると溢れる値は、制限なし (0) にならないようエラーとする
+	if v*float64(factor) >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(v * float64(factor)), nil
+}
+
+// FormatSize は、バイト数を人間が読みやすい形式に変換します
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// sizeLimiter は、ファイル単位と合計のサイズ上限を管理します
+type sizeLimiter struct {
+	maxFile      int64
+	maxTotal     int64
+	total        int64
+	skipped      int
+	skippedBytes int64
+}
+
+// allow は、指定サイズのファイルを追加できるか判定し、できない場合はその理由を返します
+// 追加できる場合は合計サイズに加算します
+func (l *sizeLimiter) allow(size int64) (string, bool) {
+	var reason string
+	switch {
+	case l.maxFile > 0 && size > l.maxFile:
+		reason = fmt.Sprintf("%s exceeds --max-file-size %s", FormatSize(size), FormatSize(l.maxFile))
+	case l.maxTotal > 0 && l.total+size > l.maxTotal:
+		reason = fmt.Sprintf("%s would exceed --max-total-size %s", FormatSize(size), FormatSize(l.maxTotal))
+	default:
+		l.total += size
+		return "", true
+	}
+
+	l.skipped++
+	l.skippedBytes += size
+	return reason, false
+}
@@ -0,0 +1,83 @@
+package scan
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"512", 512, false},
+		{"100B", 100, false},
+		{"1K", 1024, false},
+		{"2KB", 2048, false},
+		{"1kib", 1024, false},
+		{"1.5MB", 1572864, false},
+		{"1G", 1 << 30, false},
+		{" 10 MB ", 10 << 20, false},
+		{"abc", 0, true},
+		{"-1MB", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"+infinityKB", 0, true},
+		{"1e30MB", 0, true},
+		{"8589934592G", 0, true},
+		{"8EB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseSize(%q) = %d, expected %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.input); got != tt.expected {
+			t.Errorf("FormatSize(%d) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSizeLimiter(t *testing.T) {
+	l := &sizeLimiter{maxFile: 100, maxTotal: 150}
+
+	if _, ok := l.allow(80); !ok {
+		t.Error("80 bytes は許可されるべきです")
+	}
+	if _, ok := l.allow(120); ok {
+		t.Error("120 bytes は --max-file-size を超えるため除外されるべきです")
+	}
+	if _, ok := l.allow(80); ok {
+		t.Error("合計 160 bytes は --max-total-size を超えるため除外されるべきです")
+	}
+	if _, ok := l.allow(70); !ok {
+		t.Error("合計 150 bytes は許可されるべきです")
+	}
+
+	if l.skipped != 2 || l.skippedBytes != 200 {
+		t.Errorf("skipped = %d (%d bytes), expected 2 (200 bytes)", l.skipped, l.skippedBytes)
+	}
+}
@@ -0,0 +1,202 @@
+package sniff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf8"
+
+	"github.com/your-org/code2md/internal/textenc"
+)
+
+// PrefixSize は、判定に使用するファイル先頭のバイト数
+const PrefixSize = 8192
+
+// Type は、内容から判定したファイルの種類
+type Type struct {
+	Name   string // 人が読むための種類名 (例: "PNG image")
+	MIME   string // MIMEタイプ
+	Binary bool   // バイナリファイルかどうか
+}
+
+var (
+	// Text は、テキストファイルを表します
+	Text = Type{Name: "text", MIME: "text/plain"}
+	// Unknown は、種類を特定できないバイナリファイルを表します
+	Unknown = Type{Name: "binary data", MIME: "application/octet-stream", Binary: true}
+)
+
+// signature は、ファイル先頭のマジックナンバーによる判定ルール
+// check は、マジックナンバーが英数字のみでテキストの書き出しと区別できない形式で、
+// 続くヘッダーの構造を確認します (nil の場合はマジックナンバーのみで判定します)
+type signature struct {
+	offset int
+	magic  []byte
+	typ    Type
+	check  func(p []byte) bool
+}
+
+// 既知の形式のマジックナンバー
+var signatures = []signature{
+	// 画像
+	{0, []byte("\x89PNG\r\n\x1a\n"), Type{"PNG image", "image/png", true}, nil},
+	{0, []byte("\xff\xd8\xff"), Type{"JPEG image", "image/jpeg", true}, nil},
+	{0, []byte("GIF87a"), Type{"GIF image", "image/gif", true}, nil},
+	{0, []byte("GIF89a"), Type{"GIF image", "image/gif", true}, nil},
+	{0, []byte("II*\x00"), Type{"TIFF image", "image/tiff", true}, nil},
+	{0, []byte("MM\x00*"), Type{"TIFF image", "image/tiff", true}, nil},
+	{0, []byte("\x00\x00\x01\x00"), Type{"ICO image", "image/x-icon", true}, nil},
+	// 文書
+	{0, []byte("%PDF-"), Type{"PDF document", "application/pdf", true}, nil},
+	// アーカイブ・圧縮
+	{0, []byte("PK\x03\x04"), Type{"ZIP archive", "application/zip", true}, nil},
+	{0, []byte("PK\x05\x06"), Type{"ZIP archive", "application/zip", true}, nil},
+	{0, []byte("\x1f\x8b"), Type{"gzip archive", "application/gzip", true}, nil},
+	{0, []byte("\xfd7zXZ\x00"), Type{"xz archive", "application/x-xz", true}, nil},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), Type{"7-Zip archive", "application/x-7z-compressed", true}, nil},
+	{0, []byte("Rar!\x1a\x07"), Type{"RAR archive", "application/vnd.rar", true}, nil},
+	{0, []byte("\x28\xb5\x2f\xfd"), Type{"Zstandard archive", "application/zstd", true}, nil},
+	{4, []byte("\x31\x41\x59\x26\x53\x59"), Type{"bzip2 archive", "application/x-bzip2", true}, nil},
+	{257, []byte("ustar"), Type{"tar archive", "application/x-tar", true}, nil},
+	// 実行ファイル
+	{0, []byte("\x7fELF"), Type{"ELF executable", "application/x-elf", true}, nil},
+	{0, []byte("\xfe\xed\xfa\xce"), Type{"Mach-O executable", "application/x-mach-binary", true}, nil},
+	{0, []byte("\xfe\xed\xfa\xcf"), Type{"Mach-O executable", "application/x-mach-binary", true}, nil},
+	{0, []byte("\xce\xfa\xed\xfe"), Type{"Mach-O executable", "application/x-mach-binary", true}, nil},
+	{0, []byte("\xcf\xfa\xed\xfe"), Type{"Mach-O executable", "application/x-mach-binary", true}, nil},
+	{0, []byte("\x00asm"), Type{"WebAssembly module", "application/wasm", true}, nil},
+	// データベース
+	{0, []byte("SQLite format 3\x00"), Type{"SQLite database", "application/vnd.sqlite3", true}, nil},
+	// 音声・動画
+	{0, []byte("ID3"), Type{"MP3 audio", "audio/mpeg", true}, isID3},
+	{0, []byte("OggS"), Type{"Ogg media", "application/ogg", true}, isOgg},
+	{0, []byte("fLaC"), Type{"FLAC audio", "audio/flac", true}, isFLAC},
+	{4, []byte("ftyp"), Type{"MP4 media", "video/mp4", true}, isMP4},
+	// フォント
+	{0, []byte("wOFF"), Type{"WOFF font", "font/woff", true}, isWOFF},
+	{0, []byte("wOF2"), Type{"WOFF2 font", "font/woff2", true}, isWOFF},
+	{0, []byte("OTTO"), Type{"OpenType font", "font/otf", true}, isOpenType},
+	{0, []byte("\x00\x01\x00\x00\x00"), Type{"TrueType font", "font/ttf", true}, nil},
+}
+
+// RIFF コンテナ (offset 8 の形式識別子で判定)
+var riffTypes = map[string]Type{
+	"WEBP": {"WebP image", "image/webp", true},
+	"WAVE": {"WAV audio", "audio/wav", true},
+	"AVI ": {"AVI video", "video/x-msvideo", true},
+}
+
+// Detect は、ファイル先頭のデータから種類を判定します
+// 既知のマジックナンバーに一致しない場合は、テキストとして解釈できるかで判定します
+func Detect(prefix []byte) Type {
+	if t, ok := detectMagic(prefix); ok {
+		return t
+	}
+
+	// 先頭だけを読んだ場合に末尾で途切れたUTF-8の文字を取り除く
+	text := prefix
+	if len(prefix) >= PrefixSize {
+		for i := 0; i < utf8.UTFMax-1 && len(text) > 0 && !utf8.Valid(text); i++ {
+			text = text[:len(text)-1]
+		}
+		if !utf8.Valid(text) {
+			text = prefix
+		}
+	}
+	if textenc.Detect(text) == "" {
+		return Unknown
+	}
+	return Text
+}
+
+// detectMagic は、マジックナンバーから既知の形式を判定します
+func detectMagic(p []byte) (Type, bool) {
+	for _, s := range signatures {
+		if len(p) >= s.offset+len(s.magic) && bytes.Equal(p[s.offset:s.offset+len(s.magic)], s.magic) &&
+			(s.check == nil || s.check(p)) {
+			return s.typ, true
+		}
+	}
+
+	switch {
+	case len(p) >= 12 && bytes.HasPrefix(p, []byte("RIFF")):
+		if t, ok := riffTypes[string(p[8:12])]; ok {
+			return t, true
+		}
+	case len(p) >= 8 && bytes.HasPrefix(p, []byte("\xca\xfe\xba\xbe")):
+		// Java クラスファイルと Mach-O ユニバーサルバイナリは同じマジックナンバーを持つため、
+		// Java のメジャーバージョン (45以上) とアーキテクチャ数で区別する
+		if binary.BigEndian.Uint16(p[6:8]) >= 45 {
+			return Type{"Java class file", "application/java-vm", true}, true
+		}
+		return Type{"Mach-O universal binary", "application/x-mach-binary", true}, true
+	case len(p) >= 64 && bytes.HasPrefix(p, []byte("MZ")):
+		// "MZ" だけではテキストと区別できないため、PE ヘッダーの "PE\0\0" も確認する
+		// 32ビット環境で int に変換すると負になるため、uint32 のまま範囲を確認する
+		off := binary.LittleEndian.Uint32(p[0x3c:0x40])
+		if uint64(off)+4 <= uint64(len(p)) && bytes.Equal(p[off:off+4], []byte("PE\x00\x00")) {
+			return Type{"Windows executable", "application/vnd.microsoft.portable-executable", true}, true
+		}
+	case len(p) >= 14 && bytes.HasPrefix(p, []byte("BM")) && binary.LittleEndian.Uint32(p[6:10]) == 0:
+		return Type{"BMP image", "image/bmp", true}, true
+	}
+	return Type{}, false
+}
+
+// isID3 は、ID3v2 タグのヘッダー (バージョン 2〜4、未定義のフラグなし、syncsafe 整数のサイズ) を確認します
+func isID3(p []byte) bool {
+	if len(p) < 10 || p[3] < 2 || p[3] > 4 || p[4] == 0xff || p[5]&0x0f != 0 {
+		return false
+	}
+	for _, b := range p[6:10] {
+		if b >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// isOgg は、Ogg ページのヘッダー (バージョン 0、定義済みのフラグのみ) を確認します
+func isOgg(p []byte) bool {
+	return len(p) >= 27 && p[4] == 0 && p[5]&^0x07 == 0
+}
+
+// isFLAC は、最初のメタデータブロックが長さ 34 の STREAMINFO であることを確認します
+func isFLAC(p []byte) bool {
+	return len(p) >= 8 && p[4]&0x7f == 0 && p[5] == 0 && p[6] == 0 && p[7] == 34
+}
+
+// isMP4 は、先頭のボックスのサイズ (ビッグエンディアン) が妥当な値であることを確認します
+// テキストの場合は英字の並びとなり、非常に大きな値になります
+func isMP4(p []byte) bool {
+	size := binary.BigEndian.Uint32(p[0:4])
+	return size >= 8 && size < 1<<16
+}
+
+// isWOFF は、元のフォントの形式 (TrueType, OpenType, true) を確認します
+func isWOFF(p []byte) bool {
+	if len(p) < 8 {
+		return false
+	}
+	switch string(p[4:8]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+		return true
+	}
+	return false
+}
+
+// isOpenType は、テーブル数と、それから計算される searchRange を確認します
+func isOpenType(p []byte) bool {
+	if len(p) < 12 {
+		return false
+	}
+	numTables := binary.BigEndian.Uint16(p[4:6])
+	if numTables == 0 || numTables > 256 {
+		return false
+	}
+	// searchRange は numTables 以下の最大の2のべき乗 × 16
+	searchRange := uint16(16)
+	for searchRange*2 <= numTables*16 {
+		searchRange *= 2
+	}
+	return binary.BigEndian.Uint16(p[6:8]) == searchRange
+}
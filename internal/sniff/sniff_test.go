@@ -0,0 +1,74 @@
+package sniff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	// PE ヘッダーの位置 (0x3c) に 0x40 を設定した最小限の Windows 実行ファイル
+	pe := make([]byte, 0x48)
+	copy(pe, "MZ")
+	pe[0x3c] = 0x40
+	copy(pe[0x40:], "PE\x00\x00")
+
+	// PE ヘッダーの位置が範囲外 (int32 では負になる値) のファイル
+	peOverflow := make([]byte, 0x48)
+	copy(peOverflow, "MZ")
+	copy(peOverflow[0x3c:], "\xfc\xff\xff\xff")
+
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar")
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "PNG image"},
+		{"JPEG", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "JPEG image"},
+		{"GIF", []byte("GIF89a\x01\x00\x01\x00"), "GIF image"},
+		{"WebP", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "WebP image"},
+		{"PDF", []byte("%PDF-1.7\n"), "PDF document"},
+		{"ZIP", []byte("PK\x03\x04\x14\x00"), "ZIP archive"},
+		{"gzip", []byte("\x1f\x8b\x08\x00"), "gzip archive"},
+		{"tar", tar, "tar archive"},
+		{"ELF", []byte("\x7fELF\x02\x01\x01"), "ELF executable"},
+		{"PE", pe, "Windows executable"},
+		{"PE ヘッダーの位置が範囲外", peOverflow, "binary data"},
+		{"Java class", []byte("\xca\xfe\xba\xbe\x00\x00\x00\x41"), "Java class file"},
+		{"Mach-O universal", []byte("\xca\xfe\xba\xbe\x00\x00\x00\x02"), "Mach-O universal binary"},
+		{"SQLite", []byte("SQLite format 3\x00\x10\x00"), "SQLite database"},
+		{"WebAssembly", []byte("\x00asm\x01\x00\x00\x00"), "WebAssembly module"},
+		{"MP3 (ID3v2.4)", []byte("ID3\x04\x00\x00\x00\x00\x0f\x76TIT2"), "MP3 audio"},
+		{"Ogg", []byte("OggS\x00\x02" + strings.Repeat("\x00", 21)), "Ogg media"},
+		{"FLAC", []byte("fLaC\x00\x00\x00\x22\x10\x00"), "FLAC audio"},
+		{"MP4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"), "MP4 media"},
+		{"WOFF", []byte("wOFF\x00\x01\x00\x00\x00\x00\x10\x00"), "WOFF font"},
+		{"WOFF2", []byte("wOF2OTTO\x00\x00\x10\x00"), "WOFF2 font"},
+		{"OpenType", []byte("OTTO\x00\x0a\x00\x80\x00\x03\x00\x20"), "OpenType font"},
+		{"不明なバイナリ", []byte("\x00\x01\x02\x03\xff\xfe\xfd"), "binary data"},
+		{"テキスト", []byte("package main\n"), "text"},
+		{"MZで始まるテキスト", []byte("MZ" + strings.Repeat("text ", 20)), "text"},
+		{"OTTO で始まるテキスト", []byte("OTTO von Bismarck\nKonrad Adenauer\n"), "text"},
+		{"ID3 で始まるテキスト", []byte("ID3 tag parsing notes\n\nThe header is 10 bytes.\n"), "text"},
+		{"OggS で始まるテキスト", []byte("OggS pages are checksummed with CRC-32.\n"), "text"},
+		{"fLaC で始まるテキスト", []byte("fLaC streams begin with STREAMINFO.\n"), "text"},
+		{"wOFF で始まるテキスト", []byte("wOFF header layout\n"), "text"},
+		{"ftyp を含むテキスト", []byte("the ftyp box comes first\n"), "text"},
+		{"UTF-16LE テキスト", []byte("h\x00e\x00l\x00l\x00o\x00\n\x00"), "text"},
+		{"途中で途切れたUTF-8", []byte(strings.Repeat("あ", PrefixSize))[:PrefixSize], "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.data)
+			if got.Name != tt.expected {
+				t.Errorf("Detect() = %+v, expected %q", got, tt.expected)
+			}
+			if got.Binary != (tt.expected != "text") {
+				t.Errorf("Detect().Binary = %v", got.Binary)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package textenc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 文字コード名
+const (
+	UTF8      = "UTF-8"
+	UTF16LE   = "UTF-16LE"
+	UTF16BE   = "UTF-16BE"
+	ShiftJIS  = "Shift_JIS"
+	EUCJP     = "EUC-JP"
+	ISO2022JP = "ISO-2022-JP"
+	Latin1    = "ISO-8859-1"
+)
+
+// ErrBinary は、データがテキストとして解釈できないことを表します
+var ErrBinary = errors.New("data does not look like text")
+
+// 制御文字の割合がこれを超える場合はバイナリとみなす (Latin-1 判定時)
+const maxControlRatio = 0.01
+
+// Decode は、データの文字コードを判定してUTF-8の文字列に変換します
+// 判定した元の文字コード名も返します。UTF-8 の場合はデータをそのまま返します
+func Decode(data []byte) (string, string, error) {
+	enc := Detect(data)
+	switch enc {
+	case "":
+		return "", "", ErrBinary
+	case UTF8:
+		return string(data), UTF8, nil
+	}
+
+	out, err := encodingOf(enc).NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", err
+	}
+	return string(out), enc, nil
+}
+
+// NewReader は、指定した文字コードのデータをUTF-8に変換しながら読み込む Reader を返します
+// 全体を読み込まずに変換するため、大きなファイルに使用します
+func NewReader(r io.Reader, enc string) io.Reader {
+	if enc == UTF8 {
+		return r
+	}
+	return transform.NewReader(r, encodingOf(enc).NewDecoder())
+}
+
+// DetectPrefix は、ファイルの先頭部分だけから文字コードを推測します
+// 末尾で途切れたマルチバイト文字によって判定を誤らないよう、
+// 末尾の数バイトを除いてUTF-8や日本語の文字コードとして解釈できる場合はその結果を優先します
+func DetectPrefix(prefix []byte) string {
+	n := min(utf8.UTFMax, len(prefix))
+	for i := 0; i < n; i++ {
+		if trimmed := prefix[:len(prefix)-i]; utf8.Valid(trimmed) {
+			return Detect(trimmed)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if enc := Detect(prefix[:len(prefix)-i]); enc != "" && enc != Latin1 {
+			return enc
+		}
+	}
+	return Detect(prefix)
+}
+
+// Detect は、データの文字コードを推測します
+// テキストとして解釈できない場合は空文字列を返します
+func Detect(data []byte) string {
+	// BOMによる判定
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return UTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return UTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return UTF16BE
+	}
+
+	// BOMなしのUTF-16 (ASCII文字の上位バイトが0になる)
+	if enc := detectUTF16(data); enc != "" {
+		return enc
+	}
+
+	// NUL文字を含む場合はバイナリ
+	if bytes.IndexByte(data, 0) != -1 {
+		return ""
+	}
+
+	// ISO-2022-JP は7ビットのためUTF-8としても有効になるので先に判定
+	if bytes.Contains(data, []byte("\x1b$B")) || bytes.Contains(data, []byte("\x1b$@")) {
+		return ISO2022JP
+	}
+	if utf8.Valid(data) {
+		return UTF8
+	}
+
+	// 日本語の文字コード
+	if enc := detectJapanese(data); enc != "" {
+		return enc
+	}
+
+	// Latin-1 (制御文字が多い場合はバイナリとみなす)
+	control := 0
+	for _, b := range data {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != 0x1b {
+			control++
+		}
+	}
+	if float64(control) > float64(len(data))*maxControlRatio {
+		return ""
+	}
+	return Latin1
+}
+
+// detectUTF16 は、BOMのないUTF-16を偶数・奇数位置のNUL文字の偏りから判定します
+func detectUTF16(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	n := len(data) &^ 1
+	var evenZero, oddZero int
+	for i := 0; i < n; i += 2 {
+		if data[i] == 0 {
+			evenZero++
+		}
+		if data[i+1] == 0 {
+			oddZero++
+		}
+	}
+
+	half := float64(n / 2)
+	switch {
+	case float64(oddZero) > half*0.4 && float64(evenZero) < half*0.05:
+		return UTF16LE
+	case float64(evenZero) > half*0.4 && float64(oddZero) < half*0.05:
+		return UTF16BE
+	}
+	return ""
+}
+
+// detectJapanese は、Shift_JIS と EUC-JP のうち、不正なバイト列がなく
+// かな文字をより多く含む結果になる方を選びます
+func detectJapanese(data []byte) string {
+	best, bestScore := "", -1
+	for _, enc := range []string{ShiftJIS, EUCJP} {
+		out, err := encodingOf(enc).NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(out, utf8.RuneError) {
+			continue
+		}
+		score := 0
+		for _, r := range string(out) {
+			if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = enc, score
+		}
+	}
+	return best
+}
+
+// encodingOf は、文字コード名に対応するデコーダーを返します
+func encodingOf(name string) encoding.Encoding {
+	switch name {
+	case UTF16LE:
+		return xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM)
+	case UTF16BE:
+		return xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM)
+	case ShiftJIS:
+		return japanese.ShiftJIS
+	case EUCJP:
+		return japanese.EUCJP
+	case ISO2022JP:
+		return japanese.ISO2022JP
+	}
+	return charmap.ISO8859_1
+}
@@ -0,0 +1,124 @@
+package textenc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// encode は、テスト用に文字列を指定の文字コードに変換します
+func encode(t *testing.T, enc string, s string) []byte {
+	t.Helper()
+	var out []byte
+	var err error
+	switch enc {
+	case ShiftJIS:
+		out, err = japanese.ShiftJIS.NewEncoder().Bytes([]byte(s))
+	case EUCJP:
+		out, err = japanese.EUCJP.NewEncoder().Bytes([]byte(s))
+	case ISO2022JP:
+		out, err = japanese.ISO2022JP.NewEncoder().Bytes([]byte(s))
+	case UTF16LE:
+		out, err = xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM).NewEncoder().Bytes([]byte(s))
+	case UTF16BE:
+		out, err = xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM).NewEncoder().Bytes([]byte(s))
+	}
+	if err != nil {
+		t.Fatalf("%s への変換に失敗: %v", enc, err)
+	}
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	const text = "// こんにちは、世界。カタカナも含みます\nint main() { return 0; }\n"
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+		enc      string
+	}{
+		{"UTF-8", []byte(text), text, UTF8},
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, "abc"...), "\ufeffabc", UTF8},
+		{"Shift_JIS", encode(t, ShiftJIS, text), text, ShiftJIS},
+		{"EUC-JP", encode(t, EUCJP, text), text, EUCJP},
+		{"ISO-2022-JP", encode(t, ISO2022JP, text), text, ISO2022JP},
+		{"UTF-16LE BOM", append([]byte{0xFF, 0xFE}, encode(t, UTF16LE, text)...), text, UTF16LE},
+		{"UTF-16BE BOM", append([]byte{0xFE, 0xFF}, encode(t, UTF16BE, text)...), text, UTF16BE},
+		{"UTF-16LE BOMなし", encode(t, UTF16LE, "hello, world\r\n"), "hello, world\r\n", UTF16LE},
+		{"Latin-1", []byte("caf\xe9 cr\xe8me\n"), "café crème\n", Latin1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc, err := Decode(tt.data)
+			if err != nil {
+				t.Fatalf("Decode() エラー: %v", err)
+			}
+			if enc != tt.enc {
+				t.Errorf("encoding = %q, expected %q", enc, tt.enc)
+			}
+			if got != tt.expected {
+				t.Errorf("Decode() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDecodeBinary(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"NUL文字":   []byte("abc\x00def\x00\x01\x02"),
+		"制御文字が多い": []byte("\x01\x02\x03\x04\xff\xfe\x80\x81abcdef"),
+	} {
+		if _, _, err := Decode(data); err != ErrBinary {
+			t.Errorf("%s: Decode() error = %v, expected ErrBinary", name, err)
+		}
+	}
+}
+
+// 途中で途切れた先頭部分からも文字コードを判定できることを検証
+func TestDetectPrefix(t *testing.T) {
+	const text = "こんにちは、世界。カタカナも含みます"
+
+	tests := []struct {
+		name string
+		data []byte
+		enc  string
+	}{
+		{"UTF-8", []byte(text), UTF8},
+		{"Shift_JIS", encode(t, ShiftJIS, text), ShiftJIS},
+		{"EUC-JP", encode(t, EUCJP, text), EUCJP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// マルチバイト文字の途中で切る
+			for cut := 1; cut <= 2; cut++ {
+				prefix := tt.data[:len(tt.data)-cut]
+				if got := DetectPrefix(prefix); got != tt.enc {
+					t.Errorf("DetectPrefix() (末尾 %d バイト欠落) = %q, expected %q", cut, got, tt.enc)
+				}
+			}
+		})
+	}
+
+	if got := DetectPrefix([]byte("caf\xe9 cr\xe8me\n")); got != Latin1 {
+		t.Errorf("DetectPrefix() = %q, expected %q", got, Latin1)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	const text = "// こんにちは、世界\nint x;\n"
+
+	r := NewReader(bytes.NewReader(encode(t, ShiftJIS, text)), ShiftJIS)
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() エラー: %v", err)
+	}
+	if string(got) != text {
+		t.Errorf("NewReader() = %q, expected %q", got, text)
+	}
+}